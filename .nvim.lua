@@ -8,7 +8,7 @@ require('dap-go').setup {
 			name = 'Debug package args',
 			request = 'launch',
 			program = '${fileDirname}',
-			args = {"./test.bir", "./test2.bir"},
+			args = {"run", "./test.bir", "./test2.bir"},
 		},
 	},
 }
@@ -0,0 +1,86 @@
+package compiler
+
+import (
+	"fmt"
+)
+
+// Result is what Compile produced for the modules of the sources
+type Result struct {
+	// modules in the order they are compiled, imported modules first
+	Modules []string
+	// generated code of every module, llvm ir or C with BACKEND_C
+	IR map[string]string
+	// files a build writes next to its output, by file name: the interface of every module and,
+	// with ExportC, its C header
+	Artifacts map[string][]byte
+}
+
+// Compile checks the given sources, text by file name, and generates the ir of their modules.
+// Imports are looked up among the sources only and no tool is run, the data layout of a target
+// has to be given in the options.
+func Compile(sources map[string]string, options *BuildOptions) (Result, []Diagnostic) {
+	result := Result{
+		IR:        make(map[string]string),
+		Artifacts: make(map[string][]byte),
+	}
+
+	if options == nil {
+		options = &BuildOptions{}
+	}
+
+	err := compileSources(newMemoryFiles(sources), options, &result)
+	if err != nil {
+		var diagnostics []Diagnostic
+		for _, diagnostic := range toDiagnostics(err) {
+			diagnostics = append(diagnostics, *diagnostic)
+		}
+
+		return result, diagnostics
+	}
+
+	return result, nil
+}
+
+func compileSources(files memoryFiles, options *BuildOptions, result *Result) (err error) {
+	// a bug of the compiler is reported instead of stopping the program embedding it
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = internalError(recovered)
+		}
+	}()
+
+	if len(files) == 0 {
+		return fmt.Errorf("No sources given")
+	}
+
+	err, graph := checkFiles(files, files.fileNames(), options)
+	if err != nil {
+		return err
+	}
+
+	linker := newLinker(graph, options)
+	for _, compiler := range linker.compilers {
+		err := compiler.Generate()
+		if err != nil {
+			return withSource(err, files)
+		}
+
+		moduleName := compiler.module()
+
+		result.Modules = append(result.Modules, moduleName)
+		result.IR[moduleName] = compiler.Code()
+
+		err, data := encodeJSON(moduleInterface(compiler.sources()))
+		if err != nil {
+			return err
+		}
+
+		result.Artifacts[moduleName+interfaceExtension] = data
+
+		if options.ExportC {
+			result.Artifacts[moduleName+".h"] = []byte(moduleHeader(compiler))
+		}
+	}
+
+	return nil
+}
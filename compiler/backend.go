@@ -0,0 +1,71 @@
+package compiler
+
+import (
+	"io"
+	"os/exec"
+	"strings"
+)
+
+const (
+	BACKEND_LLVM = "llvm"
+	BACKEND_C    = "c"
+	BACKEND_WASM = "wasm"
+)
+
+var backendKinds = []string{BACKEND_LLVM, BACKEND_C, BACKEND_WASM}
+
+func (this *BuildOptions) backend() string {
+	if this.Backend == "" {
+		return BACKEND_LLVM
+	}
+
+	return this.Backend
+}
+
+// Backend generates the code of a checked module and turns it into an object with the toolchain
+type Backend interface {
+	// module is the name of the compiled module
+	module() string
+	// sources are the checked asts of the files of the module
+	sources() []*Node
+	// setOutput sets where the output of the toolchain goes
+	setOutput(output io.Writer)
+	// functionName is the name of a function in the object files
+	functionName(symbol *Symbol) string
+	// cacheFlags is everything besides the sources that changes the objects
+	cacheFlags() []string
+	// extension is the extension of the files holding the generated code
+	extension() string
+	Generate() error
+	// Code returns the generated code, Generate has to be called first
+	Code() string
+	EmitAssembly(outputFileName string) error
+	EmitObject(outputFileName string) error
+	Compile(outputFileName string) error
+}
+
+func newBackend(asts []*Node, moduleName string, options *BuildOptions) Backend {
+	switch options.backend() {
+	case BACKEND_C:
+		return newCCompiler(asts, moduleName, options)
+	case BACKEND_WASM:
+		return newWatCompiler(asts, moduleName, options)
+	}
+
+	return newCompiler(asts, moduleName, options)
+}
+
+// runToolchain gives the code to the toolchain through its standard input, language is the
+// argument of -x
+func runToolchain(toolchain string, language string, code string, output io.Writer, outputFileName string, arguments ...string) error {
+	arguments = append([]string{"-x", language, "-"}, arguments...)
+	arguments = append(arguments, "-o", outputFileName)
+
+	cmd := exec.Command(toolchain, arguments...)
+
+	cmd.Stdin = strings.NewReader(code)
+	cmd.Stdout = output
+	cmd.Stderr = output
+
+	return cmd.Run()
+}
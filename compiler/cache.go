@@ -0,0 +1,160 @@
+package compiler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const DefaultBuildDirectory = ".bir-build"
+
+// bumped whenever the generated code changes so stale objects are not reused
+const cacheVersion = "4"
+
+type BuildCache struct {
+	directory string
+}
+
+func newBuildCache(directory string) *BuildCache {
+	return &BuildCache{
+		directory: directory,
+	}
+}
+
+func (this *BuildCache) objectPath(moduleName string) string {
+	return filepath.Join(this.directory, moduleName+".obj")
+}
+
+func (this *BuildCache) keyPath(moduleName string) string {
+	return filepath.Join(this.directory, moduleName+".hash")
+}
+
+func (this *BuildCache) prepare() error {
+	return os.MkdirAll(this.directory, 0755)
+}
+
+func (this *BuildCache) isValid(moduleName string, key string) bool {
+	storedKey, err := os.ReadFile(this.keyPath(moduleName))
+	if err != nil || string(storedKey) != key {
+		return false
+	}
+
+	_, err = os.Stat(this.objectPath(moduleName))
+
+	return err == nil
+}
+
+func (this *BuildCache) store(moduleName string, key string) error {
+	return os.WriteFile(this.keyPath(moduleName), []byte(key), 0644)
+}
+
+func (this *BuildCache) invalidate(moduleName string) {
+	os.Remove(this.keyPath(moduleName))
+}
+
+func moduleImports(asts []*Node) []string {
+	var imports []string
+	for _, ast := range asts {
+		for imp := ast.left.right; imp != nil; imp = imp.next {
+			importName := imp.left.token.tokenValue
+			if findString(imports, importName) < 0 {
+				imports = append(imports, importName)
+			}
+		}
+	}
+
+	sort.Strings(imports)
+
+	return imports
+}
+
+func moduleSourceHash(asts []*Node) (error, string) {
+	hash := sha256.New()
+	for _, ast := range asts {
+		err, data := encodeJSON(ast)
+		if err != nil {
+			return err, ""
+		}
+
+		hash.Write(data)
+	}
+
+	return nil, hex.EncodeToString(hash.Sum(nil))
+}
+
+// writeSignature writes the shape of a declaration without token positions, so edits that
+// don't change what importers see keep the same interface hash
+func writeSignature(builder *strings.Builder, node *Node) {
+	builder.WriteString("(")
+	builder.WriteString(nodeStrings[node.nodeType])
+
+	if node.token != nil {
+		fmt.Fprintf(builder, " %s %q", tokenTypesString[node.token.tokenType], node.token.tokenValue)
+	}
+
+	if node.exported {
+		builder.WriteString(" exported")
+	}
+
+	writeSignatureList(builder, node.left)
+	writeSignatureList(builder, node.right)
+	builder.WriteString(")")
+}
+
+func writeSignatureList(builder *strings.Builder, node *Node) {
+	builder.WriteString("[")
+	for ; node != nil; node = node.next {
+		writeSignature(builder, node)
+	}
+	builder.WriteString("]")
+}
+
+func writeDeclarationsSignature(builder *strings.Builder, node *Node) {
+	for ; node != nil; node = node.next {
+		if node.nodeType == NODE_FUNCTION || node.nodeType == NODE_CONSTRUCTOR {
+			// bodies are not part of the interface
+			fmt.Fprintf(builder, "(%s %t", nodeStrings[node.nodeType], node.exported)
+			writeSignature(builder, node.left)
+			builder.WriteString(")")
+		} else if node.nodeType == NODE_IMPLEMENT {
+			fmt.Fprintf(builder, "(%s %q %t", nodeStrings[node.nodeType], node.token.tokenValue, node.exported)
+			writeSignatureList(builder, node.left)
+			writeDeclarationsSignature(builder, node.right)
+			builder.WriteString(")")
+		} else {
+			writeSignature(builder, node)
+		}
+	}
+}
+
+func moduleInterfaceHash(asts []*Node) string {
+	var builder strings.Builder
+	for _, ast := range asts {
+		writeDeclarationsSignature(&builder, ast.right)
+	}
+
+	hash := sha256.Sum256([]byte(builder.String()))
+
+	return hex.EncodeToString(hash[:])
+}
+
+func moduleCacheKey(sourceHash string, importHashes []string, flags []string) string {
+	hash := sha256.New()
+
+	fmt.Fprintf(hash, "version %s\n", cacheVersion)
+	fmt.Fprintf(hash, "source %s\n", sourceHash)
+
+	for _, importHash := range importHashes {
+		fmt.Fprintf(hash, "import %s\n", importHash)
+	}
+
+	for _, flag := range flags {
+		fmt.Fprintf(hash, "flag %s\n", flag)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,61 @@
+package compiler
+
+import (
+	"testing"
+)
+
+func linkerKeys(t *testing.T, sources map[string]string) map[string]string {
+	t.Helper()
+
+	files := newMemoryFiles(sources)
+	err, graph := checkFiles(files, files.fileNames(), &BuildOptions{})
+	if err != nil {
+		t.Fatalf("check failed: %s", err)
+	}
+
+	err, keys := newLinker(graph, &BuildOptions{}).cacheKeys()
+	if err != nil {
+		t.Fatalf("cache keys failed: %s", err)
+	}
+
+	return keys
+}
+
+func TestCacheKeyOfTransitiveImports(t *testing.T) {
+	sources := map[string]string{
+		"a.bir": "module a\nexport struct S {\n\tx: int\n}\n",
+		"b.bir": "module b\nimport a\nexport function make(): a.S {\n\treturn a.S()\n}\n",
+		"c.bir": "module c\nimport b\nfunction main(): int {\n\tvar s = b.make()\n\treturn 0\n}\n",
+	}
+
+	keys := linkerKeys(t, sources)
+
+	sources["a.bir"] = "module a\nexport struct S {\n\tx: int\n\ty: float\n}\n"
+	changedKeys := linkerKeys(t, sources)
+
+	for _, moduleName := range []string{"a", "b", "c"} {
+		if keys[moduleName] == changedKeys[moduleName] {
+			t.Errorf("key of module %s is the same after the layout of a.S changed", moduleName)
+		}
+	}
+}
+
+func TestCacheKeyOfBodies(t *testing.T) {
+	sources := map[string]string{
+		"a.bir": "module a\nexport function f(): int {\n\treturn 1\n}\n",
+		"b.bir": "module b\nimport a\nfunction main(): int {\n\treturn a.f()\n}\n",
+	}
+
+	keys := linkerKeys(t, sources)
+
+	sources["a.bir"] = "module a\nexport function f(): int {\n\treturn 2\n}\n"
+	changedKeys := linkerKeys(t, sources)
+
+	if keys["a"] == changedKeys["a"] {
+		t.Errorf("key of module a is the same after its source changed")
+	}
+
+	if keys["b"] != changedKeys["b"] {
+		t.Errorf("key of module b changed with a body of module a")
+	}
+}
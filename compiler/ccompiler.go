@@ -0,0 +1,767 @@
+package compiler
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// CCompiler generates C99 from the checked asts of a module, for toolchains that can't read llvm ir.
+// Like with Compiler, structs are used through pointers and methods take the instance as their
+// first parameter, named this. Unlike with Compiler, instances are allocated on the heap, see
+// walkConstructor.
+type CCompiler struct {
+	asts       []*Node
+	moduleName *string
+	toolchain  string
+	flags      []string
+	// exported functions keep their name, like with Compiler
+	exportC    bool
+	// where the output of the C compiler goes
+	output     io.Writer
+	code       string
+	// structs used by the module, imported structs are defined again with the same fields
+	structs    []*Symbol
+	// declarations of the called functions, by name so C functions are declared once
+	prototypes      map[string]bool
+	prototypeLines  []string
+	externs         externDeclarations
+	// body of the function being generated
+	body        strings.Builder
+	indentation int
+	// variables holding the instances built by the function
+	temporaries []string
+}
+
+func newCCompiler(asts []*Node, moduleName string, options *BuildOptions) *CCompiler {
+	return &CCompiler{
+		asts:       asts,
+		moduleName: &moduleName,
+		toolchain:  options.toolchain(),
+		flags:      append([]string{"-std=c99"}, options.clangFlags()...),
+		exportC:    options.ExportC,
+		output:     options.stderr(),
+		prototypes: make(map[string]bool),
+		externs:    make(externDeclarations),
+	}
+}
+
+func (this *CCompiler) module() string {
+	return *this.moduleName
+}
+
+func (this *CCompiler) sources() []*Node {
+	return this.asts
+}
+
+func (this *CCompiler) setOutput(output io.Writer) {
+	this.output = output
+}
+
+func (this *CCompiler) extension() string {
+	return ".c"
+}
+
+func (this *CCompiler) Code() string {
+	return this.code
+}
+
+var cKeywords = []string{
+	"auto", "break", "case", "char", "const", "continue", "default", "do", "double", "else", "enum",
+	"extern", "float", "for", "goto", "if", "inline", "int", "long", "register", "restrict", "return",
+	"short", "signed", "sizeof", "static", "struct", "switch", "typedef", "union", "unsigned", "void",
+	"volatile", "while",
+}
+
+// cName is the name of a variable or a field in C, names that are C keywords get a suffix
+func cName(name string) string {
+	if findString(cKeywords, name) >= 0 {
+		return name + "_"
+	}
+
+	return name
+}
+
+// cString writes text as a C string literal
+func cString(text string) string {
+	var builder strings.Builder
+	builder.WriteString("\"")
+
+	for index := 0; index < len(text); index++ {
+		character := text[index]
+
+		switch {
+		case character == '"' || character == '\\':
+			builder.WriteByte('\\')
+			builder.WriteByte(character)
+		case character == '\n':
+			builder.WriteString("\\n")
+		case character == '\t':
+			builder.WriteString("\\t")
+		case character < ' ' || character > '~':
+			// octal escapes stop after three digits, unlike hexadecimal ones
+			fmt.Fprintf(&builder, "\\%03o", character)
+		default:
+			builder.WriteByte(character)
+		}
+	}
+
+	builder.WriteString("\"")
+
+	return builder.String()
+}
+
+// functionName follows Compiler.functionName, but main keeps the prefix of its module so the C
+// main can call it
+func (this *CCompiler) functionName(symbol *Symbol) string {
+	if symbol.linkName == "" && symbol.name == "main" && symbol.simbolType.signature.self == nil {
+		return mangleName(*symbol.module, "", symbol.name)
+	}
+
+	return objectName(symbol, this.exportC)
+}
+
+func (this *CCompiler) useStruct(symbol *Symbol) error {
+	for _, structSymbol := range this.structs {
+		if structSymbol == symbol {
+			return nil
+		}
+	}
+
+	// added before the fields so structs referring to themselves are found
+	this.structs = append(this.structs, symbol)
+
+	for field := symbol.node.right; field != nil; field = field.next {
+		err, _ := this.convertType(&field.symbol.simbolType)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// convertType follows Compiler.convertType
+func (this *CCompiler) convertType(birType *SymbolType) (error, string) {
+	if birType.kind == TYPE_POINTER {
+		err, elementType := this.convertType(birType.element)
+		if err != nil {
+			return err, ""
+		}
+
+		return nil, elementType + "*"
+	}
+
+	if birType.kind == TYPE_INTERFACE {
+		return fmt.Errorf("Interface %s is not supported by the c backend", birType.name), ""
+	}
+
+	if birType.kind == TYPE_STRUCT {
+		err := this.useStruct(birType.symbol)
+		if err != nil {
+			return err, ""
+		}
+	}
+
+	return nil, cType(birType)
+}
+
+// prototype returns the declaration of a function, without the semicolon
+func (this *CCompiler) prototype(symbol *Symbol) (error, string) {
+	signature := symbol.simbolType.signature
+
+	err, returnType := this.convertType(signature.returnType)
+	if err != nil {
+		return err, ""
+	}
+
+	var parameters []string
+	if signature.self != nil {
+		err, selfType := this.convertType(signature.self)
+		if err != nil {
+			return err, ""
+		}
+
+		parameters = append(parameters, selfType+" this")
+	}
+
+	for _, parameter := range signature.parameters {
+		err, parameterType := this.convertType(parameter.paramType)
+		if err != nil {
+			return err, ""
+		}
+
+		parameters = append(parameters, parameterType+" "+cName(parameter.name))
+	}
+
+	if len(parameters) == 0 {
+		parameters = append(parameters, "void")
+	}
+
+	declaration := fmt.Sprintf("%s %s(%s)", returnType, this.functionName(symbol), strings.Join(parameters, ", "))
+
+	// functions that are not exported can't be referenced by other modules
+	if symbol.linkName == "" && !symbol.exported && symbol.name != "main" {
+		declaration = "static " + declaration
+	}
+
+	return nil, declaration
+}
+
+// declare adds the declaration of a function the module calls or defines
+func (this *CCompiler) declare(symbol *Symbol) error {
+	if symbol.linkName != "" {
+		err, declared := this.externs.add(symbol)
+		if err != nil || declared {
+			return err
+		}
+	}
+
+	name := this.functionName(symbol)
+	if this.prototypes[name] {
+		return nil
+	}
+
+	err, declaration := this.prototype(symbol)
+	if err != nil {
+		return err
+	}
+
+	this.prototypes[name] = true
+	this.prototypeLines = append(this.prototypeLines, declaration+";")
+
+	return nil
+}
+
+func (this *CCompiler) writeLine(format string, arguments ...any) {
+	this.body.WriteString(strings.Repeat("\t", this.indentation))
+	fmt.Fprintf(&this.body, format, arguments...)
+	this.body.WriteString("\n")
+}
+
+func (this *CCompiler) walkBinaryExpression(node *Node) (error, string) {
+	err, leftValue := this.walkExpression(node.left)
+	if err != nil {
+		return err, ""
+	}
+
+	err, rightValue := this.walkExpression(node.right)
+	if err != nil {
+		return err, ""
+	}
+
+	operators := map[int]string{
+		TOKEN_PLUS:          "+",
+		TOKEN_MINUS:         "-",
+		TOKEN_MULTIPLY:      "*",
+		TOKEN_DIVIDE:        "/",
+		TOKEN_AND:           "&&",
+		TOKEN_OR:            "||",
+		TOKEN_EQUAL:         "==",
+		TOKEN_DIFFERENT:     "!=",
+		TOKEN_GREATER:       ">",
+		TOKEN_GREATER_EQUAL: ">=",
+		TOKEN_LESS:          "<",
+		TOKEN_LESS_EQUAL:    "<=",
+	}
+
+	operator, ok := operators[node.token.tokenType]
+	if !ok {
+		return nodeDiagnostic(node, "Invalid operation"), ""
+	}
+
+	return nil, "(" + leftValue + " " + operator + " " + rightValue + ")"
+}
+
+func (this *CCompiler) walkArguments(argumentsNode *Node, arguments []string) (error, string) {
+	for argument := argumentsNode.right; argument != nil; argument = argument.next {
+		err, argumentValue := this.walkExpression(argument)
+		if err != nil {
+			return err, ""
+		}
+
+		arguments = append(arguments, argumentValue)
+	}
+
+	return nil, strings.Join(arguments, ", ")
+}
+
+// walkConstructor allocates an instance on the heap, the fields are initialized before init is
+// called. Compiler allocates instances on the stack of the function building them, so they can't
+// be returned from it. Here they can, but they are never freed: a constructor called in a loop
+// takes memory until the program ends
+func (this *CCompiler) walkConstructor(symbol *Symbol, argumentsNode *Node) (error, string) {
+	err := this.useStruct(symbol)
+	if err != nil {
+		return err, ""
+	}
+
+	structName := cStructName(symbol)
+	instance := fmt.Sprintf("instance%d", len(this.temporaries))
+	this.temporaries = append(this.temporaries, structName+"* "+instance+";")
+
+	parts := []string{
+		fmt.Sprintf("%s = malloc(sizeof(%s))", instance, structName),
+		fmt.Sprintf("*%s = (%s){0}", instance, structName),
+	}
+
+	for field := symbol.node.right; field != nil; field = field.next {
+		if field.symbol == nil || field.right == nil {
+			continue
+		}
+
+		err, fieldValue := this.walkExpression(field.right)
+		if err != nil {
+			return err, ""
+		}
+
+		parts = append(parts, instance+"->"+cName(field.token.tokenValue)+" = "+fieldValue)
+	}
+
+	if initSymbol, ok := (*symbol.node.symbolTable)["init"]; ok {
+		err := this.declare(initSymbol)
+		if err != nil {
+			return err, ""
+		}
+
+		err, arguments := this.walkArguments(argumentsNode, []string{instance})
+		if err != nil {
+			return err, ""
+		}
+
+		parts = append(parts, this.functionName(initSymbol)+"("+arguments+")")
+	}
+
+	parts = append(parts, instance)
+
+	return nil, "(" + strings.Join(parts, ", ") + ")"
+}
+
+func (this *CCompiler) walkCall(node *Node) (error, string) {
+	callee := node.left
+
+	var symbol *Symbol
+	var arguments []string
+
+	if callee.nodeType == NODE_VARIABLE {
+		symbol = callee.symbol
+	} else if callee.nodeType == NODE_MEMBER_ACCESS && callee.left.symbolType.kind == TYPE_MODULE {
+		// member of an imported module
+		symbol = callee.symbol
+	} else if callee.nodeType == NODE_MEMBER_ACCESS {
+		structType := callee.left.symbolType
+		if structType.kind == TYPE_INTERFACE {
+			return nodeDiagnostic(callee, "Calls through interface %s are not supported by the c backend", structType.name), ""
+		}
+
+		method, ok := (*structType.symbol.node.symbolTable)[callee.token.tokenValue]
+		if !ok || method.simbolType.kind != TYPE_FUNCTION {
+			return tokenDiagnostic(callee.token, "Struct %s has no method %s", structType.name, callee.token.tokenValue), ""
+		}
+
+		err, instance := this.walkExpression(callee.left)
+		if err != nil {
+			return err, ""
+		}
+
+		symbol = method
+		arguments = append(arguments, instance)
+	} else {
+		return nodeDiagnostic(callee, "Only functions can be called"), ""
+	}
+
+	if symbol.simbolType.kind == TYPE_STRUCT {
+		return this.walkConstructor(symbol, node.right)
+	}
+
+	err := this.declare(symbol)
+	if err != nil {
+		return err, ""
+	}
+
+	err, argumentList := this.walkArguments(node.right, arguments)
+	if err != nil {
+		return err, ""
+	}
+
+	return nil, this.functionName(symbol) + "(" + argumentList + ")"
+}
+
+func (this *CCompiler) walkExpression(node *Node) (error, string) {
+	switch node.nodeType {
+	case NODE_INT:
+		_, err := strconv.ParseInt(node.token.tokenValue, 10, 64)
+		if err != nil {
+			return err, ""
+		}
+
+		return nil, "INT64_C(" + node.token.tokenValue + ")"
+	case NODE_FLOAT:
+		floatValue, err := strconv.ParseFloat(node.token.tokenValue, 64)
+		if err != nil {
+			return err, ""
+		}
+
+		text := strconv.FormatFloat(floatValue, 'g', -1, 64)
+		if !strings.ContainsAny(text, ".e") {
+			text += ".0"
+		}
+
+		return nil, text
+	case NODE_STRING:
+		return nil, cString(node.token.tokenValue)
+	case NODE_BOOL:
+		if node.token.tokenType == TOKEN_TRUE {
+			return nil, "1"
+		}
+
+		return nil, "0"
+	case NODE_VARIABLE:
+		return nil, cName(node.symbol.name)
+	case NODE_NOT:
+		err, value := this.walkExpression(node.left)
+		if err != nil {
+			return err, ""
+		}
+
+		return nil, "!" + value
+	case NODE_BINARY_EXPRESSION:
+		return this.walkBinaryExpression(node)
+	case NODE_CAST:
+		err, value := this.walkExpression(node.left)
+		if err != nil {
+			return err, ""
+		}
+
+		err, castType := this.convertType(node.symbolType)
+		if err != nil {
+			return err, ""
+		}
+
+		return nil, "((" + castType + ")" + value + ")"
+	case NODE_ADDRESS:
+		err, value := this.walkExpression(node.left)
+		if err != nil {
+			return err, ""
+		}
+
+		return nil, "(&" + value + ")"
+	case NODE_DEREFERENCE:
+		err, value := this.walkExpression(node.left)
+		if err != nil {
+			return err, ""
+		}
+
+		return nil, "(*" + value + ")"
+	case NODE_CALL:
+		return this.walkCall(node)
+	case NODE_MEMBER_ACCESS:
+		if node.left.symbolType.kind == TYPE_MODULE {
+			return nodeDiagnostic(node, "Variables of module %s are not supported by the c backend", node.left.symbolType.name), ""
+		}
+
+		if node.left.symbolType.kind == TYPE_INTERFACE {
+			return nodeDiagnostic(node, "Interface %s is not supported by the c backend", node.left.symbolType.name), ""
+		}
+
+		err, value := this.walkExpression(node.left)
+		if err != nil {
+			return err, ""
+		}
+
+		return nil, value + "->" + cName(node.token.tokenValue)
+	}
+
+	return nodeDiagnostic(node, "Can't compile %s", nodeStrings[node.nodeType]), ""
+}
+
+func (this *CCompiler) walk(node *Node) error {
+	for ; node != nil; node = node.next {
+		switch node.nodeType {
+		case NODE_RETURN:
+			if node.left == nil {
+				this.writeLine("return;")
+				continue
+			}
+
+			err, returnValue := this.walkExpression(node.left)
+			if err != nil {
+				return err
+			}
+
+			this.writeLine("return %s;", returnValue)
+		case NODE_ASSIGNMENT:
+			err, destination := this.walkExpression(node.left)
+			if err != nil {
+				return err
+			}
+
+			err, assignmentValue := this.walkExpression(node.right)
+			if err != nil {
+				return err
+			}
+
+			this.writeLine("%s = %s;", destination, assignmentValue)
+		case NODE_VARIABLE_DECLARATION:
+			err, variableType := this.convertType(&node.symbol.simbolType)
+			if err != nil {
+				return err
+			}
+
+			// variables start at zero like in the interpreter
+			initValue := "0"
+			if node.right != nil {
+				err, initValue = this.walkExpression(node.right)
+				if err != nil {
+					return err
+				}
+			}
+
+			this.writeLine("%s %s = %s;", variableType, cName(node.symbol.name), initValue)
+		case NODE_IF, NODE_WHILE:
+			err, condition := this.walkExpression(node.left)
+			if err != nil {
+				return err
+			}
+
+			keyword := "if"
+			if node.nodeType == NODE_WHILE {
+				keyword = "while"
+			}
+
+			this.writeLine("%s (%s) {", keyword, condition)
+
+			err = this.walkBlock(node.right.left)
+			if err != nil {
+				return err
+			}
+
+			if node.right.right != nil {
+				this.writeLine("} else {")
+
+				err = this.walkBlock(node.right.right)
+				if err != nil {
+					return err
+				}
+			}
+
+			this.writeLine("}")
+		case NODE_UNSAFE:
+			this.writeLine("{")
+
+			err := this.walkBlock(node.left)
+			if err != nil {
+				return err
+			}
+
+			this.writeLine("}")
+		default:
+			err, value := this.walkExpression(node)
+			if err != nil {
+				return err
+			}
+
+			this.writeLine("%s;", value)
+		}
+	}
+
+	return nil
+}
+
+func (this *CCompiler) walkBlock(node *Node) error {
+	this.indentation++
+	err := this.walk(node)
+	this.indentation--
+
+	return err
+}
+
+// walkFunction returns the definition of a function, with the instances it builds declared first
+func (this *CCompiler) walkFunction(node *Node) (error, string) {
+	symbol := node.left.symbol
+
+	err, declaration := this.prototype(symbol)
+	if err != nil {
+		return err, ""
+	}
+
+	this.body.Reset()
+	this.temporaries = nil
+	this.indentation = 1
+
+	err = this.walk(node.right)
+	if err != nil {
+		return err, ""
+	}
+
+	var builder strings.Builder
+	builder.WriteString(declaration + " {\n")
+
+	for _, temporary := range this.temporaries {
+		builder.WriteString("\t" + temporary + "\n")
+	}
+
+	builder.WriteString(this.body.String())
+	builder.WriteString("}\n")
+
+	return nil, builder.String()
+}
+
+// walkRoot returns the definitions of the functions and methods of a file
+func (this *CCompiler) walkRoot(node *Node) (error, []string) {
+	var definitions []string
+	for ; node != nil; node = node.next {
+		if node.nodeType == NODE_IMPLEMENT {
+			err, methods := this.walkRoot(node.right)
+			if err != nil {
+				return err, nil
+			}
+
+			definitions = append(definitions, methods...)
+		} else if node.nodeType == NODE_FUNCTION || node.nodeType == NODE_CONSTRUCTOR {
+			err := this.declare(node.left.symbol)
+			if err != nil {
+				return err, nil
+			}
+
+			err, definition := this.walkFunction(node)
+			if err != nil {
+				return inSymbolFile(err, node.left.symbol), nil
+			}
+
+			definitions = append(definitions, definition)
+		} else if node.nodeType == NODE_EXTERN {
+			err := this.declare(node.left.symbol)
+			if err != nil {
+				return err, nil
+			}
+		} else if node.nodeType == NODE_STRUCT {
+			err := this.useStruct(node.symbol)
+			if err != nil {
+				return err, nil
+			}
+		} else if node.nodeType == NODE_VARIABLE_DECLARATION {
+			return inSymbolFile(nodeDiagnostic(node, "Root variables are not supported by the c backend"), node.symbol), nil
+		}
+	}
+
+	return nil, definitions
+}
+
+// mainFunction returns the C main calling the main function of the module, if it has one
+func (this *CCompiler) mainFunction() string {
+	for _, ast := range this.asts {
+		symbol, ok := (*ast.symbolTable)["main"]
+		if !ok || symbol.simbolType.kind != TYPE_FUNCTION || symbol.linkName != "" {
+			continue
+		}
+
+		if symbol.simbolType.signature.returnType.name == "void" {
+			return fmt.Sprintf("int main(void) {\n\t%s();\n\treturn 0;\n}\n", this.functionName(symbol))
+		}
+
+		return fmt.Sprintf("int main(void) {\n\treturn (int)%s();\n}\n", this.functionName(symbol))
+	}
+
+	return ""
+}
+
+func (this *CCompiler) Generate() error {
+	this.structs = nil
+	this.prototypes = make(map[string]bool)
+	this.prototypeLines = nil
+	this.externs = make(externDeclarations)
+
+	var definitions []string
+	for _, ast := range this.asts {
+		err, functions := this.walkRoot(ast.right)
+		if err != nil {
+			return err
+		}
+
+		definitions = append(definitions, functions...)
+	}
+
+	if main := this.mainFunction(); main != "" {
+		definitions = append(definitions, main)
+	}
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "/* generated by bir from module %s, do not edit */\n\n", *this.moduleName)
+	builder.WriteString("#include <stddef.h>\n#include <stdint.h>\n\n")
+
+	// stdlib.h is not included, it declares C functions that bir code can declare differently
+	builder.WriteString("void* malloc(size_t size);\n\n")
+
+	for _, symbol := range this.structs {
+		fmt.Fprintf(&builder, "typedef struct %s %s;\n", cStructName(symbol), cStructName(symbol))
+	}
+
+	if len(this.structs) > 0 {
+		builder.WriteString("\n")
+	}
+
+	for _, symbol := range this.structs {
+		fmt.Fprintf(&builder, "struct %s {\n", cStructName(symbol))
+
+		fields := 0
+		for field := symbol.node.right; field != nil; field = field.next {
+			err, fieldType := this.convertType(&field.symbol.simbolType)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(&builder, "\t%s %s;\n", fieldType, cName(field.token.tokenValue))
+			fields++
+		}
+
+		// C has no empty structs
+		if fields == 0 {
+			builder.WriteString("\tchar empty;\n")
+		}
+
+		builder.WriteString("};\n\n")
+	}
+
+	for _, prototype := range this.prototypeLines {
+		builder.WriteString(prototype + "\n")
+	}
+
+	for _, definition := range definitions {
+		builder.WriteString("\n" + definition)
+	}
+
+	this.code = builder.String()
+
+	return nil
+}
+
+func (this *CCompiler) runCompiler(outputFileName string, arguments ...string) error {
+	return runToolchain(this.toolchain, "c", this.code, this.output, outputFileName, arguments...)
+}
+
+func (this *CCompiler) EmitAssembly(outputFileName string) error {
+	return this.runCompiler(outputFileName, append([]string{"-S"}, this.flags...)...)
+}
+
+func (this *CCompiler) objectFlags() []string {
+	return append([]string{"-c"}, this.flags...)
+}
+
+func (this *CCompiler) cacheFlags() []string {
+	return append([]string{BACKEND_C, this.toolchain, strconv.FormatBool(this.exportC)}, this.objectFlags()...)
+}
+
+func (this *CCompiler) EmitObject(outputFileName string) error {
+	return this.runCompiler(outputFileName, this.objectFlags()...)
+}
+
+func (this *CCompiler) Compile(outputFileName string) error {
+	err := this.Generate()
+	if err != nil {
+		return err
+	}
+
+	return this.EmitObject(outputFileName)
+}
@@ -0,0 +1,76 @@
+package compiler
+
+import "testing"
+
+func functionSymbol(modulePath string, name string, self *SymbolType) *Symbol {
+	return &Symbol{
+		name:       name,
+		module:     &modulePath,
+		simbolType: SymbolType{kind: TYPE_FUNCTION, signature: &Signature{self: self}},
+	}
+}
+
+func TestCFunctionNames(t *testing.T) {
+	compiler := newCCompiler(nil, "a", &BuildOptions{})
+
+	structType := &SymbolType{kind: TYPE_STRUCT, name: "b"}
+
+	tests := []struct {
+		symbol *Symbol
+		name   string
+	}{
+		{functionSymbol("a.b", "f", nil), "a_b_f"},
+		{functionSymbol("a_b", "f", nil), "a_0b_f"},
+		{functionSymbol("a", "f", structType), "a_b__f"},
+		{functionSymbol("a", "b_f", nil), "a_b_0f"},
+		{functionSymbol("a", "main", nil), "a_main"},
+		{&Symbol{name: "write", linkName: "write"}, "write"},
+	}
+
+	names := make(map[string]bool)
+	for _, test := range tests {
+		name := compiler.functionName(test.symbol)
+		if name != test.name {
+			t.Errorf("%s: got %s, expected %s", test.symbol.name, name, test.name)
+		}
+
+		if names[name] {
+			t.Errorf("%s is the name of two functions", name)
+		}
+		names[name] = true
+	}
+}
+
+func TestCUnsupportedConstructs(t *testing.T) {
+	tests := []struct {
+		name    string
+		sources map[string]string
+		error   string
+	}{
+		{"root variable", map[string]string{"main.bir": "module main\nconst COUNT = 1\nfunction main(): int {\n\treturn 0\n}\n"}, "main.bir:2:7: Root variables are not supported by the c backend"},
+		{"module variable", map[string]string{
+			"a.bir":    "module a\nexport const COUNT = 1\n",
+			"main.bir": "module main\nimport a\nfunction main(): int {\n\treturn a.COUNT\n}\n",
+		}, "main.bir:4:9: Variables of module a are not supported by the c backend"},
+	}
+
+	for _, test := range tests {
+		files := newMemoryFiles(test.sources)
+		err, graph := checkFiles(files, files.fileNames(), &BuildOptions{})
+		if err != nil {
+			t.Fatalf("%s: check failed: %s", test.name, err)
+		}
+
+		err = newBackend(graph.modules["main"], "main", &BuildOptions{Backend: BACKEND_C}).Generate()
+		if err == nil {
+			t.Errorf("%s: generate succeeded", test.name)
+			continue
+		}
+
+		// the errors of the backend are diagnostics, with the file and the span of the construct
+		diagnostics, ok := asDiagnostics(err)
+		if !ok || len(diagnostics) != 1 || diagnostics[0].Error() != test.error {
+			t.Errorf("%s: got %v, expected %s", test.name, err, test.error)
+		}
+	}
+}
@@ -0,0 +1,1301 @@
+package compiler
+
+import (
+	"strings"
+
+	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+)
+
+const (
+	TYPE_MODULE   = iota
+	TYPE_CONST    = iota
+	TYPE_LITERAL  = iota
+	TYPE_VARIABLE = iota
+	TYPE_FUNCTION = iota
+	TYPE_STRUCT   = iota
+	TYPE_INTERFACE = iota
+	TYPE_EXPRESSION = iota
+	TYPE_POINTER = iota
+	// type of the expressions that have an error, the checks involving it pass so the error
+	// is reported only once
+	TYPE_ERROR = iota
+)
+
+type Parameter struct {
+	name string
+	paramType *SymbolType
+	node *Node
+}
+
+type Signature struct {
+	parameters[] *Parameter
+	returnType *SymbolType
+	self *SymbolType
+}
+
+type SymbolType struct {
+	kind int
+	name string
+	symbol *Symbol
+	signature *Signature
+	// type pointed to by raw pointers
+	element *SymbolType
+}
+
+type Symbol struct {
+	name       string
+	simbolType SymbolType
+	node 	   *Node
+	value      value.Value
+	structType  *types.StructType
+	module     *string
+	fileName   *string
+	exported   bool
+	// name of the C function an extern function is linked with
+	linkName   string
+}
+
+type SymbolTable map[string]*Symbol
+
+var errorType = &SymbolType {kind: TYPE_ERROR, name: "<error>"}
+
+// Checker checks the files of one module, they share the root scope
+type Checker struct {
+	asts []*Node
+	fileNames []string
+	moduleName *string
+	fileName *string
+	imports map[string]string
+	// imports of every file, by ast
+	fileImports map[*Node]map[string]string
+	// checked asts of every module, by module path
+	modules map[string][]*Node
+	symbolTables  *Stack[*SymbolTable]
+	functionStack *Stack[*Symbol]
+	currentStruct *SymbolType
+	// number of unsafe blocks the statement being checked is in
+	unsafeDepth int
+	// errors found so far, the check goes on after them
+	diagnostics Diagnostics
+}
+
+func newChecker(asts []*Node, fileNames []string, modules map[string][]*Node) *Checker {
+	moduleName := astModuleName(asts[0])
+
+	return &Checker {
+		asts: asts,
+		fileNames: fileNames,
+		moduleName: &moduleName,
+		fileImports: make(map[*Node]map[string]string),
+		modules: modules,
+		symbolTables:  &Stack[*SymbolTable]{},
+		functionStack: &Stack[*Symbol]{},
+	}
+}
+
+// report records an error of the file being checked, an error found again at the same place,
+// like the type of a parameter checked with the function and with its body, is recorded once
+func (this *Checker) report(err error) {
+	for _, diagnostic := range toDiagnostics(err) {
+		if diagnostic.File == "" && this.fileName != nil {
+			diagnostic.File = *this.fileName
+		}
+
+		if !this.reported(diagnostic) {
+			this.diagnostics = append(this.diagnostics, diagnostic)
+		}
+	}
+}
+
+func (this *Checker) reported(diagnostic *Diagnostic) bool {
+	for _, existing := range this.diagnostics {
+		if existing.File == diagnostic.File && existing.Start == diagnostic.Start && existing.Message == diagnostic.Message {
+			return true
+		}
+	}
+
+	return false
+}
+
+// reportedErrors returns the errors reported since the last call, nil when there are none
+func (this *Checker) reportedErrors() error {
+	if len(this.diagnostics) == 0 {
+		return nil
+	}
+
+	diagnostics := this.diagnostics
+	this.diagnostics = nil
+
+	return diagnostics
+}
+
+func (this *Checker) symbolAlreadyExists(symbolName string) bool {
+	lastScope := *this.symbolTables.peek()
+	
+	_, ok := lastScope[symbolName]
+
+	return ok
+}
+
+func (this *Checker) alreadyDeclaredError(kind string, symbolName string, node *Node) error {
+	var token *Token
+	if node != nil {
+		token = node.token
+	}
+
+	diagnostic := tokenDiagnostic(token, "%s already declared in current scope: %s", kind, symbolName)
+
+	existing := (*this.symbolTables.peek())[symbolName]
+	if existing.node != nil && existing.node.token != nil && existing.fileName != nil {
+		diagnostic.note("previous declaration: %s:%d:%d", *existing.fileName, existing.node.token.line, existing.node.token.column)
+	}
+
+	return diagnostic
+}
+
+func (this *Checker) addVariableSymbol(varName string, varType *SymbolType, node *Node) error {
+	if this.symbolAlreadyExists(varName) {
+		return this.alreadyDeclaredError("variable", varName, node)
+	}
+
+	lastScope := *this.symbolTables.peek()
+
+	lastScope[varName] = &Symbol {
+		name: varName,
+		simbolType: *varType,
+		node: node,
+		fileName: this.fileName,
+	}
+
+	if node != nil {
+		node.symbol = lastScope[varName]
+		node.symbol.exported = node.exported
+	}
+
+	return nil
+}
+
+func (this *Checker) addFunctionSymbol(functionName string, returnType *SymbolType, parametersTypes []*Parameter, node *Node, self *SymbolType) (error, *Symbol) {
+	if this.symbolAlreadyExists(functionName) {
+		return this.alreadyDeclaredError("function", functionName, node), nil
+	}
+
+	lastScope := *this.symbolTables.peek()
+
+	lastScope[functionName] = &Symbol {
+		name: functionName,
+		simbolType: SymbolType {
+			kind: TYPE_FUNCTION,
+			name: functionName,
+			signature: &Signature {
+				parameters: parametersTypes,
+				returnType: returnType,
+				self: self,
+			},
+		},
+		node: node,
+		module: this.moduleName,
+		fileName: this.fileName,
+		exported: node.exported,
+	}
+
+	node.symbol = lastScope[functionName]
+	lastScope[functionName].simbolType.symbol = node.symbol
+
+	return nil, lastScope[functionName]
+}
+
+func (this *Checker) searchSymbol(symbolName string, node *Node) (error, *Symbol) {
+	var foundSymbol *Symbol = nil
+	if modulePath, ok := this.imports[symbolName]; ok {
+		return nil, &Symbol {
+			name: symbolName,
+			simbolType: SymbolType {
+				kind: TYPE_MODULE,
+				name: modulePath,
+			},
+		}
+	}
+
+	this.symbolTables.foreach(func (item *SymbolTable) (stop bool) {
+		val, ok := (*item)[symbolName]
+		if !ok {
+			return false
+		}
+
+		foundSymbol = val
+
+		return true
+	})
+
+	if foundSymbol == nil {
+		return tokenDiagnostic(node.token, "Symbol not declarated: %s", symbolName), nil
+	}
+
+	return nil, foundSymbol
+}
+
+// isForeign tells if a symbol was declared by another module
+func (this *Checker) isForeign(symbol *Symbol) bool {
+	return symbol.module != nil && *symbol.module != *this.moduleName
+}
+
+// searchModuleSymbol looks up a top-level symbol of an imported module
+func (this *Checker) searchModuleSymbol(modulePath string, symbolName string, node *Node) (error, *Symbol) {
+	asts, ok := this.modules[modulePath]
+	if !ok {
+		return tokenDiagnostic(node.token, "Module not loaded: %s", modulePath), nil
+	}
+
+	for _, ast := range asts {
+		if ast.symbolTable == nil {
+			return tokenDiagnostic(node.token, "Module not checked yet: %s", modulePath), nil
+		}
+
+		if symbol, ok := (*ast.symbolTable)[symbolName]; ok {
+			if !symbol.exported {
+				diagnostic := tokenDiagnostic(node.token, "Symbol not exported by module %s: %s", modulePath, symbolName)
+				if symbol.fileName != nil && symbol.node != nil && symbol.node.token != nil {
+					diagnostic.note("declared at: %s:%d:%d", *symbol.fileName, symbol.node.token.line, symbol.node.token.column)
+				}
+
+				return diagnostic, nil
+			}
+
+			return nil, symbol
+		}
+	}
+
+	return tokenDiagnostic(node.token, "Symbol not declarated in module %s: %s", modulePath, symbolName), nil
+}
+
+func pointerType(elementType *SymbolType) *SymbolType {
+	return &SymbolType {
+		kind: TYPE_POINTER,
+		name: "ptr<" + elementType.name + ">",
+		element: elementType,
+	}
+}
+
+// requireUnsafe rejects the raw pointer operations done outside of an unsafe block
+func (this *Checker) requireUnsafe(operation string, node *Node) error {
+	if this.unsafeDepth > 0 {
+		return nil
+	}
+
+	return nodeDiagnostic(node, "%s is only allowed in unsafe blocks", operation)
+}
+
+// isAddressable tells if the expression is stored in memory, so its address can be taken
+func isAddressable(node *Node) bool {
+	switch node.nodeType {
+	case NODE_VARIABLE:
+		return node.symbol.node != nil && (node.symbol.node.nodeType == NODE_VARIABLE_DECLARATION || node.symbol.node.nodeType == NODE_PARAMETER)
+	case NODE_MEMBER_ACCESS:
+		return node.left.symbolType.kind != TYPE_MODULE && node.symbolType.kind != TYPE_FUNCTION
+	case NODE_DEREFERENCE:
+		return true
+	}
+
+	return false
+}
+
+// castAllowed tells if a value can be converted from a type to another, the casts involving raw
+// pointers are the unsafe ones
+func castAllowed(fromType *SymbolType, toType *SymbolType) (allowed bool, unsafe bool) {
+	if fromType.kind == TYPE_POINTER || toType.kind == TYPE_POINTER {
+		fromInteger := fromType.kind == TYPE_POINTER || fromType.name == "int"
+		toInteger := toType.kind == TYPE_POINTER || toType.name == "int"
+
+		return fromInteger && toInteger, true
+	}
+
+	if fromType.name == toType.name {
+		return true, false
+	}
+
+	return (fromType.name == "int" || fromType.name == "float") && (toType.name == "int" || toType.name == "float"), false
+}
+
+func (this *Checker) getTypeFromNode(node *Node) (error, *SymbolType) {
+	if node.nodeType == NODE_INT_TYPE {
+		return nil, &SymbolType {kind: TYPE_LITERAL, name: "int"}
+	}
+
+	if node.nodeType == NODE_FLOAT_TYPE {
+		return nil, &SymbolType {kind: TYPE_LITERAL, name: "float"}
+	}
+
+	if node.nodeType == NODE_STRING_TYPE {
+		return nil, &SymbolType {kind: TYPE_LITERAL, name: "string"}
+	}
+
+	if node.nodeType == NODE_BOOL_TYPE {
+		return nil, &SymbolType {kind: TYPE_LITERAL, name: "bool"}
+	}
+
+	if node.nodeType == NODE_POINTER_TYPE {
+		if node.left == nil || node.left.next != nil {
+			return tokenDiagnostic(node.token, "Pointer type needs one type to point to"), nil
+		}
+
+		err, elementType := this.getTypeFromNode(node.left)
+		if err != nil {
+			return err, nil
+		}
+
+		return nil, pointerType(elementType)
+	}
+
+	if node.nodeType == NODE_CUSTOM_TYPE {
+		var symbol *Symbol
+
+		// types of other modules are qualified by the name the module is imported as
+		if importName, typeName, qualified := strings.Cut(node.token.tokenValue, "."); qualified {
+			modulePath, ok := this.imports[importName]
+			if !ok {
+				return tokenDiagnostic(node.token, "Module not imported: %s", importName), nil
+			}
+
+			var err error
+			err, symbol = this.searchModuleSymbol(modulePath, typeName, node)
+			if err != nil {
+				return err, nil
+			}
+		} else {
+			var err error
+			err, symbol = this.searchSymbol(node.token.tokenValue, node)
+			if err != nil {
+				return err, nil
+			}
+		}
+
+		if symbol.simbolType.kind != TYPE_STRUCT && symbol.simbolType.kind != TYPE_INTERFACE {
+			return tokenDiagnostic(node.token, "Not a type: %s", node.token.tokenValue), nil
+		}
+
+		return nil, &symbol.simbolType
+	}
+
+	return nodeDiagnostic(node, "Invalid node"), nil
+}
+
+func (this *Checker) expressionAllowed(node *Node, expressionType string) bool {
+	if node.token.tokenType == TOKEN_EQUAL || node.token.tokenType == TOKEN_DIFFERENT {
+		switch expressionType {
+		case "int":
+			fallthrough
+		case "float":
+			fallthrough
+		case "string":
+			fallthrough
+		case "bool":
+			return true
+		}
+
+		return false
+	}
+
+ 	if node.token.tokenType == TOKEN_LESS ||
+		node.token.tokenType == TOKEN_LESS_EQUAL ||
+		node.token.tokenType == TOKEN_GREATER ||
+		node.token.tokenType == TOKEN_GREATER_EQUAL ||
+		node.token.tokenType == TOKEN_PLUS ||
+		node.token.tokenType == TOKEN_MINUS ||
+		node.token.tokenType == TOKEN_MULTIPLY ||
+		node.token.tokenType == TOKEN_DIVIDE {
+		switch expressionType {
+		case "int":
+			fallthrough
+		case "float":
+			return true
+		}
+
+		return false
+	}
+
+ 	if node.token.tokenType == TOKEN_AND ||
+		node.token.tokenType == TOKEN_OR {
+		switch expressionType {
+		case "bool":
+			return true
+		}
+
+		return false
+	}
+
+	return true
+}
+
+func (this *Checker) expressionResultType(node *Node, expressionType *SymbolType) (error, *SymbolType) {
+	if node.token.tokenType == TOKEN_EQUAL {
+		return nil, &SymbolType {kind: TYPE_LITERAL, name: "bool"}
+	}
+
+	if node.token.tokenType == TOKEN_DIFFERENT {
+		return nil, &SymbolType {kind: TYPE_LITERAL, name: "bool"}
+	}
+
+	if node.token.tokenType == TOKEN_GREATER {
+		return nil, &SymbolType {kind: TYPE_LITERAL, name: "bool"}
+	}
+
+	if node.token.tokenType == TOKEN_GREATER_EQUAL {
+		return nil, &SymbolType {kind: TYPE_LITERAL, name: "bool"}
+	}
+
+	if node.token.tokenType == TOKEN_LESS {
+		return nil, &SymbolType {kind: TYPE_LITERAL, name: "bool"}
+	}
+
+	if node.token.tokenType == TOKEN_LESS_EQUAL {
+		return nil, &SymbolType {kind: TYPE_LITERAL, name: "bool"}
+	}
+
+	if node.token.tokenType == TOKEN_AND {
+		return nil, &SymbolType {kind: TYPE_LITERAL, name: "bool"}
+	}
+
+	if node.token.tokenType == TOKEN_OR {
+		return nil, &SymbolType {kind: TYPE_LITERAL, name: "bool"}
+	}
+
+	return nil, expressionType
+}
+
+// checkArguments checks the arguments of a call against the parameters of the called function,
+// callNode is the call the arguments are reported on
+func (this *Checker) checkArguments(signature *Signature, callNode *Node) error {
+	var arguments []*Node
+	var argumentTypes []*SymbolType
+	for argument := callNode.right.right; argument != nil; argument = argument.next {
+		err, argumentType := this.determineType(argument)
+		if err != nil {
+			return err
+		}
+
+		arguments = append(arguments, argument)
+		argumentTypes = append(argumentTypes, argumentType)
+	}
+
+	var parameters []*Parameter
+	if signature != nil {
+		parameters = signature.parameters
+	}
+
+	if len(parameters) != len(argumentTypes) {
+		return nodeDiagnostic(callNode, "Not the same number of arguments: %d, %d", len(parameters), len(argumentTypes)).
+			note("expected %d arguments, found %d", len(parameters), len(argumentTypes))
+	}
+
+	for i := 0; i < len(parameters); i++ {
+		if !this.isAssignable(parameters[i].paramType, argumentTypes[i]) {
+			return nodeDiagnostic(arguments[i], "Invalid argument type for parameter %s", parameters[i].name).
+				note("expected %s, found %s", parameters[i].paramType.name, argumentTypes[i].name)
+		}
+	}
+
+	return nil
+}
+
+// determineType reports the errors of the expression, the expression then has the error type
+func (this *Checker) determineType(node *Node) (error, *SymbolType) {
+	err, symbolType := this.determineExpressionType(node)
+	if err != nil {
+		this.report(err)
+		symbolType = errorType
+	}
+
+	node.symbolType = symbolType
+
+	return nil, symbolType
+}
+
+func (this *Checker) determineExpressionType(node *Node) (error, *SymbolType) {
+	if node.nodeType == NODE_INT {
+		return nil, &SymbolType {kind: TYPE_LITERAL, name: "int"}
+	}
+
+	if node.nodeType == NODE_FLOAT {
+		return nil, &SymbolType {kind: TYPE_LITERAL, name: "float"}
+	}
+
+	if node.nodeType == NODE_BOOL {
+		return nil, &SymbolType {kind: TYPE_LITERAL, name: "bool"}
+	}
+
+	if node.nodeType == NODE_STRING {
+		return nil, &SymbolType {kind: TYPE_LITERAL, name: "string"}
+	}
+
+	if node.nodeType == NODE_VARIABLE {
+		err, symbol := this.searchSymbol(node.token.tokenValue, node)
+		if err != nil {
+			return err, nil
+		}
+
+		node.symbol = symbol
+
+		return nil, &symbol.simbolType
+	}
+
+	if node.nodeType == NODE_NOT {
+		err, symbolType := this.determineType(node.left)
+		if err != nil {
+			return err, nil
+		}
+
+		if symbolType.kind != TYPE_ERROR && symbolType.name != "bool" {
+			return nodeDiagnostic(node, "Can't apply not on non bool type").note("found %s", symbolType.name), nil
+		}
+
+		return nil, symbolType
+	}
+
+	if node.nodeType == NODE_ADDRESS {
+		err := this.requireUnsafe("Taking an address", node)
+		if err != nil {
+			return err, nil
+		}
+
+		err, symbolType := this.determineType(node.left)
+		if err != nil {
+			return err, nil
+		}
+
+		if symbolType.kind == TYPE_ERROR {
+			return nil, errorType
+		}
+
+		if !isAddressable(node.left) {
+			return nodeDiagnostic(node, "Can't take the address of this expression"), nil
+		}
+
+		return nil, pointerType(symbolType)
+	}
+
+	if node.nodeType == NODE_DEREFERENCE {
+		err := this.requireUnsafe("Dereferencing a pointer", node)
+		if err != nil {
+			return err, nil
+		}
+
+		err, symbolType := this.determineType(node.left)
+		if err != nil {
+			return err, nil
+		}
+
+		if symbolType.kind == TYPE_ERROR {
+			return nil, errorType
+		}
+
+		if symbolType.kind != TYPE_POINTER {
+			return nodeDiagnostic(node, "Can't dereference non pointer type %s", symbolType.name), nil
+		}
+
+		return nil, symbolType.element
+	}
+
+	if node.nodeType == NODE_CAST {
+		err, fromType := this.determineType(node.left)
+		if err != nil {
+			return err, nil
+		}
+
+		err, toType := this.getTypeFromNode(node.right)
+		if err != nil {
+			return err, nil
+		}
+
+		if fromType.kind == TYPE_ERROR {
+			return nil, toType
+		}
+
+		allowed, unsafe := castAllowed(fromType, toType)
+		if !allowed {
+			return nodeDiagnostic(node, "Can't cast %s to %s", fromType.name, toType.name), nil
+		}
+
+		if unsafe {
+			err := this.requireUnsafe("Casting a pointer", node)
+			if err != nil {
+				return err, nil
+			}
+		}
+
+		return nil, toType
+	}
+
+	if node.nodeType == NODE_BINARY_EXPRESSION {
+		err, typeLeft := this.determineType(node.left)
+		if err != nil {
+			return err, nil
+		}
+
+		err, typeRight := this.determineType(node.right)
+		if err != nil {
+			return err, nil
+		}
+
+		if typeLeft.kind == TYPE_ERROR || typeRight.kind == TYPE_ERROR {
+			return nil, errorType
+		}
+
+		if typeLeft.kind == TYPE_POINTER && typeRight.name == "int" && (node.token.tokenType == TOKEN_PLUS || node.token.tokenType == TOKEN_MINUS) {
+			err := this.requireUnsafe("Pointer arithmetic", node)
+			if err != nil {
+				return err, nil
+			}
+
+			return nil, typeLeft
+		}
+
+		if typeLeft.kind == TYPE_POINTER && this.sameType(typeLeft, typeRight) && (node.token.tokenType == TOKEN_EQUAL || node.token.tokenType == TOKEN_DIFFERENT) {
+			return this.expressionResultType(node, typeLeft)
+		}
+
+		if typeLeft.name != typeRight.name {
+			return nodeDiagnostic(node, "invalid operation between different types: %s and %s", typeLeft.name, typeRight.name), nil
+		}
+
+		if !this.expressionAllowed(node, typeLeft.name) {
+			return nodeDiagnostic(node, "expression not allowed for type %s", typeLeft.name), nil
+		}
+
+		return this.expressionResultType(node, typeLeft)
+	}
+
+	if node.nodeType == NODE_CALL {
+		err, symbolType := this.determineType(node.left)
+		if err != nil {
+			return err, nil
+		}
+
+		if symbolType.kind == TYPE_STRUCT {
+			// constructor, the arguments are the ones of init
+			var initSignature *Signature
+			if initSymbol, ok := (*symbolType.symbol.node.symbolTable)["init"]; ok {
+				if this.isForeign(symbolType.symbol) && !initSymbol.exported {
+					return nodeDiagnostic(node.left, "constructor not exported by module %s: %s", *symbolType.symbol.module, symbolType.name), nil
+				}
+
+				initSignature = initSymbol.simbolType.signature
+			}
+
+			err := this.checkArguments(initSignature, node)
+			if err != nil {
+				return err, nil
+			}
+
+			return nil, symbolType
+		}
+
+		if symbolType.kind == TYPE_ERROR {
+			// the arguments are checked for their own errors
+			for argument := node.right.right; argument != nil; argument = argument.next {
+				this.determineType(argument)
+			}
+
+			return nil, errorType
+		}
+
+		if symbolType.kind != TYPE_FUNCTION {
+			return nodeDiagnostic(node.left, "Only functions can be called").note("found %s", symbolType.name), nil
+		}
+
+		err = this.checkArguments(symbolType.signature, node)
+		if err != nil {
+			return err, nil
+		}
+
+		return nil, symbolType.signature.returnType
+	}
+
+	if node.nodeType == NODE_MEMBER_ACCESS {
+		err, memberType := this.determineType(node.left)
+		if err != nil {
+			return err, nil
+		}
+
+		if memberType.kind == TYPE_ERROR {
+			return nil, errorType
+		}
+
+		if memberType.kind == TYPE_MODULE {
+			err, symbol := this.searchModuleSymbol(memberType.name, node.token.tokenValue, node)
+			if err != nil {
+				return err, nil
+			}
+
+			node.symbol = symbol
+
+			return nil, &symbol.simbolType
+		}
+
+		// literal and pointer types have no symbol, so the kind is checked first
+		if memberType.kind != TYPE_STRUCT && memberType.kind != TYPE_INTERFACE {
+			return nodeDiagnostic(node, "Can only access field of struct or interface").note("found %s", memberType.name), nil
+		}
+
+		symbol := memberType.symbol
+		structSymbol := symbol
+
+		symbol, ok := (*symbol.node.symbolTable)[node.token.tokenValue]
+		if !ok {
+			return tokenDiagnostic(node.token, "member does not exist in struct or interface: %s", node.token.tokenValue).note("in %s", structSymbol.name), nil
+		}
+
+		if this.isForeign(structSymbol) && !symbol.exported {
+			return tokenDiagnostic(node.token, "member not exported by module %s: %s", *structSymbol.module, node.token.tokenValue), nil
+		}
+
+		return nil, &symbol.simbolType
+	}
+
+	if node.nodeType == NODE_VARIABLE_DECLARATION {
+		var initializationSymbolType *SymbolType
+		if node.right != nil {
+			err, initializationSymbol := this.determineType(node.right)
+			if err != nil {
+				return err, nil
+			}
+
+			initializationSymbolType = initializationSymbol
+		}
+
+		// the variable is declared even when it has errors, so its uses don't report it again
+		var variableSymbolType *SymbolType = nil
+		if node.left != nil {
+			err, symbolType := this.getTypeFromNode(node.left)
+			if err != nil {
+				this.report(err)
+				symbolType = errorType
+			}
+
+			variableSymbolType = symbolType
+		}
+
+		if variableSymbolType == nil {
+			variableSymbolType = initializationSymbolType
+		}
+
+		if initializationSymbolType != nil && !this.isAssignable(variableSymbolType, initializationSymbolType) {
+			this.report(nodeDiagnostic(node, "can't initialize with different types").note("expected %s, found %s", variableSymbolType.name, initializationSymbolType.name))
+		}
+
+		err := this.addVariableSymbol(node.token.tokenValue, variableSymbolType, node)
+		if err != nil {
+			return err, nil
+		}
+
+		return nil, &node.symbol.simbolType
+	}
+
+	return nodeDiagnostic(node, "Can't check type"), nil
+}
+
+func (this *Checker) enterScope(node *Node) {
+	symbolTable := make(SymbolTable)
+	node.symbolTable = &symbolTable
+	this.symbolTables.push(&symbolTable)
+}
+
+func (this *Checker) leaveScope() {
+	this.symbolTables.pop()
+}
+
+func (this *Checker) implementsInterface(leftSymbol *Symbol, rightSymbol *Symbol) bool {
+	for _, member := range (*leftSymbol.node.symbolTable) {
+		value, ok := (*rightSymbol.node.symbolTable)[member.name]
+		if !ok {
+			return false
+		}
+
+		if value.simbolType.kind != TYPE_FUNCTION {
+			continue
+		}
+
+		if !this.sameType(value.simbolType.signature.returnType, member.simbolType.signature.returnType) {
+			return false
+		}
+
+		if len(value.simbolType.signature.parameters) != len(member.simbolType.signature.parameters) {
+			return false
+		}
+
+		for index, parameter := range value.simbolType.signature.parameters {
+			if !this.sameType(parameter.paramType, member.simbolType.signature.parameters[index].paramType) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func (this *Checker) sameType(leftSymbolType *SymbolType, rightSymbolType *SymbolType) bool {
+	return sameType(leftSymbolType, rightSymbolType)
+}
+
+// sameType compares structs and interfaces by their declaration, so types with the same name
+// from different modules are not mixed up
+func sameType(leftSymbolType *SymbolType, rightSymbolType *SymbolType) bool {
+	if leftSymbolType.kind == TYPE_STRUCT || leftSymbolType.kind == TYPE_INTERFACE {
+		return leftSymbolType.symbol == rightSymbolType.symbol
+	}
+
+	if leftSymbolType.kind == TYPE_POINTER {
+		return rightSymbolType.kind == TYPE_POINTER && sameType(leftSymbolType.element, rightSymbolType.element)
+	}
+
+	return leftSymbolType.name == rightSymbolType.name
+}
+
+// sameSignature compares the types of two signatures, the names of the parameters don't matter
+func sameSignature(left *Signature, right *Signature) bool {
+	if len(left.parameters) != len(right.parameters) || !sameType(left.returnType, right.returnType) {
+		return false
+	}
+
+	for index := range left.parameters {
+		if !sameType(left.parameters[index].paramType, right.parameters[index].paramType) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (this *Checker) isAssignable(leftSymbolType *SymbolType, rightSymbolType *SymbolType) bool {
+	if leftSymbolType.kind == TYPE_ERROR || rightSymbolType.kind == TYPE_ERROR {
+		return true
+	}
+
+	if this.sameType(leftSymbolType, rightSymbolType) {
+		return true
+	}
+
+	if leftSymbolType.kind != TYPE_INTERFACE || rightSymbolType.kind != TYPE_STRUCT {
+		return false
+	}
+
+	return this.implementsInterface(leftSymbolType.symbol, rightSymbolType.symbol)
+}
+
+func (this *Checker) addFunctionDeclaration(node *Node) (error, *Symbol) {
+	symbolName := node.token.tokenValue
+
+	var symbolType *SymbolType
+	if node.left.left == nil {
+		symbolType = &SymbolType {
+			kind: TYPE_LITERAL,
+			name: "void",
+		}
+	} else {
+		var err error
+		err, symbolType = this.getTypeFromNode(node.left.left)
+		if err != nil {
+			this.report(err)
+			symbolType = errorType
+		}
+	}
+
+	var signature []*Parameter
+	for parameter := node.right; parameter != nil; parameter = parameter.next {
+		err, parameterType := this.getTypeFromNode(parameter.left)
+		if err != nil {
+			this.report(err)
+			parameterType = errorType
+		}
+
+		signature = append(signature, &Parameter {
+				name: parameter.token.tokenValue,
+				paramType: parameterType,
+				node: parameter,
+			},
+		)
+	}
+
+	return this.addFunctionSymbol(symbolName, symbolType, signature, node, this.currentStruct)
+}
+
+func (this *Checker) walkStatements(node *Node) error {
+	err, _ := this.walkGetLastStatement(node)
+	
+	return err
+}
+
+func (this *Checker) walkGetLastStatement(node *Node) (error, *Node) {
+	var lastNode *Node = nil
+	for node != nil {
+		if node.nodeType == NODE_IF || node.nodeType == NODE_WHILE {
+			this.enterScope(node)
+
+			err, symbolType := this.determineType(node.left)
+			if err != nil {
+				return err, nil
+			}
+
+			if symbolType.kind != TYPE_ERROR && symbolType.name != "bool" {
+				this.report(nodeDiagnostic(node.left, "Can't have non-bool in if").note("found %s", symbolType.name))
+			}
+
+			branchNode := node.right
+
+			if branchNode.left != nil {
+				this.enterScope(branchNode.left)
+
+				err = this.walkStatements(branchNode.left)
+				if err != nil {
+					return err, nil
+				}
+
+				this.leaveScope()
+			}
+
+			if branchNode.right != nil {
+				this.enterScope(branchNode.right)
+
+				err = this.walkStatements(branchNode.right)
+				if err != nil {
+					return err, nil
+				}
+
+				this.leaveScope()
+			}
+
+			this.leaveScope()
+		} else if node.nodeType == NODE_UNSAFE {
+			this.enterScope(node)
+			this.unsafeDepth++
+
+			err := this.walkStatements(node.left)
+			if err != nil {
+				return err, nil
+			}
+
+			this.unsafeDepth--
+			this.leaveScope()
+		} else if node.nodeType == NODE_ASSIGNMENT {
+			err, leftSymbolType := this.determineType(node.left)
+			if err != nil {
+				return err, nil
+			}
+
+			err, rightSymbolType := this.determineType(node.right)
+			if err != nil {
+				return err, nil
+			}
+
+			if !this.isAssignable(leftSymbolType, rightSymbolType) {
+				this.report(nodeDiagnostic(node, "Can't assign different types").note("expected %s, found %s", leftSymbolType.name, rightSymbolType.name))
+			}
+		} else if node.nodeType == NODE_RETURN {
+			// a return without a value returns void
+			symbolType := &SymbolType {kind: TYPE_LITERAL, name: "void"}
+			if node.left != nil {
+				var err error
+				err, symbolType = this.determineType(node.left)
+				if err != nil {
+					return err, nil
+				}
+			}
+
+			// statements of the repl are outside of any function
+			if this.functionStack.len() == 0 {
+				this.report(nodeDiagnostic(node, "Return can only be inside a function"))
+			} else if returnType := this.functionStack.peek().simbolType.signature.returnType; !this.isAssignable(returnType, symbolType) {
+				this.report(nodeDiagnostic(node, "Invalid return type").note("expected %s, found %s", returnType.name, symbolType.name))
+			}
+		} else {
+			err, _ := this.determineType(node)
+			if err != nil {
+				return err, nil
+			}
+		}
+
+		lastNode = node
+		node = node.next
+	}
+
+	return nil, lastNode
+}
+
+func (this *Checker) addTypeHeader(value string, typeType int, node *Node) error {
+	if this.symbolAlreadyExists(value) {
+		return this.alreadyDeclaredError("type", value, node)
+	}
+
+	lastScope := *this.symbolTables.peek()
+	lastScope[value] = &Symbol {
+		name: value,
+		simbolType: SymbolType {
+			kind: typeType,
+			name: value,
+		},
+		node: node,
+		module: this.moduleName,
+		fileName: this.fileName,
+		exported: node.exported,
+	}
+
+	node.symbol = lastScope[value]
+	lastScope[value].simbolType.symbol = lastScope[value]
+
+	return nil
+}
+
+func (this *Checker) walkRootTypes(node *Node) error {
+	for node != nil {
+		if node.nodeType == NODE_STRUCT {
+			err := this.addTypeHeader(node.token.tokenValue, TYPE_STRUCT, node)
+			if err != nil {
+				this.report(err)
+			}
+
+			// create the symbol table early in case implement statement appear before struct declaration statement
+			this.enterScope(node)
+			this.leaveScope()
+		} else if node.nodeType == NODE_INTERFACE {
+			err := this.addTypeHeader(node.token.tokenValue, TYPE_INTERFACE, node)
+			if err != nil {
+				this.report(err)
+			}
+		}
+
+		node = node.next
+	}
+
+	return nil
+}
+
+func (this *Checker) walkRootDeclarations(node *Node) error {
+	for node != nil {
+		if node.nodeType == NODE_STRUCT {
+			this.symbolTables.push(node.symbolTable)
+			
+			err := this.walkStatements(node.right)
+			if err != nil {
+				return err
+			}
+
+			this.symbolTables.pop()
+		} else if node.nodeType == NODE_IMPLEMENT {
+			structName := node.token.tokenValue
+
+			err, symbol := this.searchSymbol(structName, node)
+			if err != nil {
+				// the functions are left without symbols, walk skips them
+				this.report(err)
+				node = node.next
+				continue
+			}
+
+			if symbol.simbolType.kind != TYPE_STRUCT {
+				this.report(tokenDiagnostic(node.token, "Only structs can be implemented").note("%s is not a struct", structName))
+				node = node.next
+				continue
+			}
+
+			// push the struct symbol table
+			this.symbolTables.push(symbol.node.symbolTable)
+
+			this.currentStruct = &symbol.simbolType
+
+			err = this.walkRootDeclarations(node.right)
+			if err != nil {
+				return err
+			}
+
+			this.currentStruct = nil
+
+			this.symbolTables.pop()
+		} else if node.nodeType == NODE_INTERFACE {
+			this.enterScope(node)
+
+			err := this.walkRootDeclarations(node.right)
+			if err != nil {
+				return err
+			}
+
+			this.leaveScope()
+		} else if node.nodeType == NODE_FUNCTION || node.nodeType == NODE_CONSTRUCTOR {
+			err, symbol := this.addFunctionDeclaration(node.left)
+			if err != nil {
+				this.report(err)
+				node = node.next
+				continue
+			}
+
+			// export is recorded on the function, not on its declaration
+			symbol.exported = node.exported
+		} else if node.nodeType == NODE_EXTERN {
+			err, symbol := this.addFunctionDeclaration(node.left)
+			if err != nil {
+				this.report(err)
+				node = node.next
+				continue
+			}
+
+			symbol.exported = node.exported
+
+			symbol.linkName = symbol.name
+			if node.token != nil {
+				symbol.linkName = node.token.tokenValue
+			}
+		} else if node.nodeType == NODE_FUNCTION_DECLARATION {
+			err, _ := this.addFunctionDeclaration(node)
+			if err != nil {
+				this.report(err)
+			}
+		} else if node.nodeType == NODE_VARIABLE_DECLARATION {
+			// root constants are declared with the functions, so every body can use them
+			err, _ := this.determineType(node)
+			if err != nil {
+				this.report(err)
+			}
+		}
+
+		node = node.next
+	}
+
+	return nil
+}
+
+func (this *Checker) walk(node *Node) error {
+	for node != nil {
+		// functions whose declaration has errors have no symbol, their body is not checked
+		if (node.nodeType == NODE_FUNCTION || node.nodeType == NODE_CONSTRUCTOR) && node.left.symbol == nil {
+			node = node.next
+			continue
+		}
+
+		if node.nodeType == NODE_IMPLEMENT {
+			err := this.walk(node.right)
+			if err != nil {
+				return err
+			}
+		} else if node.nodeType == NODE_FUNCTION || node.nodeType == NODE_CONSTRUCTOR {
+			symbol := node.left.symbol
+
+			this.functionStack.push(symbol)
+			this.enterScope(node.left)
+
+			// declare this
+			currentFunction := this.functionStack.peek()
+
+			self := currentFunction.simbolType.signature.self
+			if self != nil {
+				err := this.addVariableSymbol("this", self, nil)
+				if err != nil {
+					return err
+				}
+			}
+
+			err := this.walkStatements(node.left.right)
+			if err != nil {
+				return err
+			}
+
+			err, lastStatement := this.walkGetLastStatement(node.right)
+			if err != nil {
+				return err
+			}
+
+			if symbol.simbolType.signature.returnType.name != "void" && (lastStatement == nil || lastStatement.nodeType != NODE_RETURN) {
+				this.report(tokenDiagnostic(node.left.token, "function needs to end with return").note("the function returns %s", symbol.simbolType.signature.returnType.name))
+			}
+
+			this.leaveScope()
+			this.functionStack.pop()
+		}
+
+		node = node.next
+	}
+
+	return nil
+}
+
+func (this *Checker) walkImports(node *Node) error {
+	// add imports to symbol tables somehow
+	imports := make(map[string]string)
+
+	for imp := node.right; imp != nil; imp = imp.next {
+		modulePath := imp.left.token.tokenValue
+
+		// without an alias the module is bound under the last component of its path
+		name := modulePath[strings.LastIndex(modulePath, ".")+1:]
+		if imp.token != nil {
+			name = imp.token.tokenValue
+		}
+
+		if _, ok := imports[name]; ok {
+			this.report(tokenDiagnostic(imp.left.token, "Import name already used: %s", name))
+			continue
+		}
+
+		imports[name] = modulePath
+	}
+
+	this.imports = imports
+
+	return nil
+}
+
+// forEachFile walks every file of the module with the imports of that file
+func (this *Checker) forEachFile(walk func(ast *Node) error) error {
+	for index, ast := range this.asts {
+		this.fileName = &this.fileNames[index]
+		this.imports = this.fileImports[ast]
+
+		err := walk(ast)
+		if err != nil {
+			return inFile(err, this.fileNames[index], "")
+		}
+	}
+
+	return nil
+}
+
+func (this *Checker) Check() error {
+	symbolTable := make(SymbolTable)
+	for _, ast := range this.asts {
+		ast.symbolTable = &symbolTable
+	}
+	this.symbolTables.push(&symbolTable)
+
+	err := this.forEachFile(func(ast *Node) error {
+		err := this.walkImports(ast.left)
+		this.fileImports[ast] = this.imports
+
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	// types first, so every file can use the types declared in the others
+	err = this.forEachFile(func(ast *Node) error {
+		return this.walkRootTypes(ast.right)
+	})
+	if err != nil {
+		return err
+	}
+
+	err = this.forEachFile(func(ast *Node) error {
+		return this.walkRootDeclarations(ast.right)
+	})
+	if err != nil {
+		return err
+	}
+
+	err = this.forEachFile(func(ast *Node) error {
+		return this.walk(ast.right)
+	})
+	if err != nil {
+		return err
+	}
+
+	return this.reportedErrors()
+}
@@ -0,0 +1,134 @@
+package compiler
+
+import (
+	"strings"
+	"testing"
+)
+
+// checkSources checks the sources and returns their diagnostics
+func checkSources(t *testing.T, sources map[string]string) error {
+	t.Helper()
+
+	files := newMemoryFiles(sources)
+	err, _ := checkFiles(files, files.fileNames(), &BuildOptions{})
+
+	return err
+}
+
+func TestMemberAccessOfLiteral(t *testing.T) {
+	err := checkSources(t, map[string]string{
+		"main.bir": "module main\nfunction main(): int {\n\tvar a = 1\n\treturn a.b\n}\n",
+	})
+
+	if err == nil || !strings.Contains(err.Error(), "Can only access field of struct or interface") {
+		t.Errorf("expected an access of a field of int, got %v", err)
+	}
+
+	if err != nil && strings.Contains(err.Error(), "Internal compiler error") {
+		t.Errorf("the checker panicked: %s", err)
+	}
+}
+
+func TestReturnWithoutValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		found string
+	}{
+		{"void function", "module c\nfunction f() {\n\treturn\n}\n", ""},
+		{"int function", "module c\nfunction f(): int {\n\treturn\n}\n", "expected int, found void"},
+		{"value in void function", "module c\nfunction f() {\n\treturn 1\n}\n", "expected void, found int"},
+	}
+
+	for _, test := range tests {
+		err := checkSources(t, map[string]string{"c.bir": test.text})
+
+		if test.found == "" {
+			if err != nil {
+				t.Errorf("%s: check failed: %s", test.name, err)
+			}
+			continue
+		}
+
+		if err == nil {
+			t.Errorf("%s: expected an invalid return type, %s", test.name, test.found)
+			continue
+		}
+
+		diagnostics := toDiagnostics(err)
+		if len(diagnostics) != 1 || diagnostics[0].Error() != "c.bir:3:2: Invalid return type" || !strings.Contains(diagnostics[0].Render(), test.found) {
+			t.Errorf("%s: expected an invalid return type, %s, got %v", test.name, test.found, err)
+		}
+	}
+}
+
+func TestDiagnosticsWithParseErrors(t *testing.T) {
+	err := checkSources(t, map[string]string{
+		"d.bir": "module d\nfunction d(): int {\n\treturn 1 +\n}\nfunction g(): int {\n\treturn $\n}\n",
+		"e.bir": "module e\nfunction e(): int {\n\treturn \"a\"\n}\n",
+		"f.bir": "module f\nfunction f(): int {\n\treturn true\n}\n",
+		"q.bir": "modul q\n",
+		"u.bir": "module u\nimport d\nfunction u(): int {\n\treturn d.x\n}\n",
+		"w.bir": "module w\nimport q\nfunction w(): int {\n\treturn q.x\n}\n",
+	})
+
+	if err == nil {
+		t.Fatalf("check succeeded")
+	}
+
+	// the modules that parsed are checked, the modules importing a file with errors are not
+	expected := []string{
+		"d.bir:4:1: Unexpected token: { tokenType: TOKEN_CLOSED_BRACKET, tokenValue: }",
+		"d.bir:6:9: Invalid token",
+		"e.bir:3:2: Invalid return type",
+		"f.bir:3:2: Invalid return type",
+		"q.bir:1:1: Invalid token: { tokenType: TOKEN_IDENTIFIER, tokenValue: modul}",
+	}
+
+	diagnostics := toDiagnostics(err)
+	if len(diagnostics) != len(expected) {
+		t.Fatalf("got %d diagnostics, expected %d:\n%s", len(diagnostics), len(expected), err)
+	}
+
+	for index, diagnostic := range diagnostics {
+		if diagnostic.Error() != expected[index] {
+			t.Errorf("got %s, expected %s", diagnostic.Error(), expected[index])
+		}
+	}
+}
+
+func TestRootConstants(t *testing.T) {
+	sources := map[string]string{
+		"a.bir":    "module a\nexport const LIMIT = 7\n",
+		"main.bir": "module main\nimport a\nconst BASE = 5\nfunction main(): int {\n\treturn BASE + a.LIMIT\n}\n",
+	}
+
+	files := newMemoryFiles(sources)
+	err, graph := checkFiles(files, files.fileNames(), &BuildOptions{})
+	if err != nil {
+		t.Fatalf("check failed: %s", err)
+	}
+
+	err, status := newInterpreter(graph, nil).Run("main")
+	if err != nil {
+		t.Fatalf("run failed: %s", err)
+	}
+
+	if status != 12 {
+		t.Errorf("got exit status %d, expected 12", status)
+	}
+
+	// the exported constants are declared by the interface of the module too
+	err, data := encodeJSON(moduleInterface(graph.modules["a"]))
+	if err != nil {
+		t.Fatalf("encode failed: %s", err)
+	}
+
+	err = checkSources(t, map[string]string{
+		"a" + interfaceExtension: string(data),
+		"main.bir":               sources["main.bir"],
+	})
+	if err != nil {
+		t.Errorf("check with the interface failed: %s", err)
+	}
+}
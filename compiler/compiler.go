@@ -0,0 +1,1055 @@
+package compiler
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/llir/llvm/ir"
+	"github.com/llir/llvm/ir/constant"
+	"github.com/llir/llvm/ir/enum"
+	"github.com/llir/llvm/ir/types"
+	"github.com/llir/llvm/ir/value"
+)
+
+type Compiler struct {
+	asts               []*Node
+	irModule           *ir.Module
+	symbolTables       Stack[*SymbolTable]
+	currentFunction    *ir.Func
+	currentStruct      *types.StructType
+	blocks             Stack[*ir.Block]
+	currentInstance    value.Value
+	constructor 	   bool
+	moduleName		   *string
+	toolchain          string
+	flags              []string
+	// exported functions keep their name, so C code can call them
+	exportC            bool
+	// where the output of clang goes
+	output             io.Writer
+	// declarations of the functions and structs of imported modules
+	externalFunctions  map[*Symbol]*ir.Func
+	externalStructs    map[*Symbol]*types.StructType
+	externs            externDeclarations
+}
+
+func newCompiler(asts []*Node, moduleName string, options *BuildOptions) *Compiler {
+	m := ir.NewModule()
+	m.TargetTriple = options.Target
+	m.DataLayout = options.DataLayout
+
+	return &Compiler{
+		asts:               asts,
+		irModule:           m,
+		symbolTables:       Stack[*SymbolTable]{},
+		blocks:             Stack[*ir.Block]{},
+		moduleName: &moduleName,
+		toolchain: options.toolchain(),
+		flags: options.clangFlags(),
+		exportC: options.ExportC,
+		output: options.stderr(),
+		externalFunctions:  make(map[*Symbol]*ir.Func),
+		externalStructs:    make(map[*Symbol]*types.StructType),
+		externs:            make(externDeclarations),
+	}
+}
+
+func (this *Compiler) module() string {
+	return *this.moduleName
+}
+
+func (this *Compiler) sources() []*Node {
+	return this.asts
+}
+
+func (this *Compiler) setOutput(output io.Writer) {
+	this.output = output
+}
+
+func (this *Compiler) extension() string {
+	return ".ll"
+}
+
+func (this *Compiler) Code() string {
+	return this.irModule.String()
+}
+
+func (this *Compiler) isExternal(symbol *Symbol) bool {
+	return symbol.module != nil && *symbol.module != *this.moduleName
+}
+
+// structTypeOf returns the ir type of a struct, structs of imported modules are declared again in
+// this module with the same layout
+func (this *Compiler) structTypeOf(symbol *Symbol) (error, *types.StructType) {
+	if !this.isExternal(symbol) {
+		return nil, symbol.structType
+	}
+
+	if structType, ok := this.externalStructs[symbol]; ok {
+		return nil, structType
+	}
+
+	structType := types.NewStruct()
+	this.irModule.NewTypeDef(*symbol.module+"."+symbol.name, structType)
+
+	// added before the fields so structs referring to themselves are found
+	this.externalStructs[symbol] = structType
+
+	err := this.convertFields(symbol, structType)
+	if err != nil {
+		return err, nil
+	}
+
+	return nil, structType
+}
+
+// functionValue returns the ir function of a function symbol, functions of imported modules are
+// declared in this module and resolved by the linker
+func (this *Compiler) functionValue(symbol *Symbol) (error, value.Value) {
+	if !this.isExternal(symbol) {
+		return nil, symbol.value
+	}
+
+	if function, ok := this.externalFunctions[symbol]; ok {
+		return nil, function
+	}
+
+	// the same C function can be declared by several modules
+	if symbol.linkName != "" {
+		err, declared := this.externs.add(symbol)
+		if err != nil {
+			return err, nil
+		}
+
+		if declared {
+			return nil, this.declaredFunction(symbol.linkName)
+		}
+	}
+
+	err, returnType, parameters := this.functionSignature(symbol.simbolType.signature)
+	if err != nil {
+		return err, nil
+	}
+
+	function := this.irModule.NewFunc(this.functionName(symbol), returnType, parameters...)
+	if symbol.linkName != "" {
+		function.CallingConv = enum.CallingConvC
+	}
+
+	this.externalFunctions[symbol] = function
+
+	return nil, function
+}
+
+func (this *Compiler) declaredFunction(name string) *ir.Func {
+	for _, function := range this.irModule.Funcs {
+		if function.Name() == name {
+			return function
+		}
+	}
+
+	return nil
+}
+
+func (this *Compiler) functionSignature(birSignature *Signature) (error, types.Type, []*ir.Param) {
+	err, returnType := this.convertType(birSignature.returnType)
+	if err != nil {
+		return err, nil, nil
+	}
+
+	var parameters []*ir.Param
+	if birSignature.self != nil {
+		err, paramType := this.convertType(birSignature.self)
+		if err != nil {
+			return err, nil, nil
+		}
+
+		parameters = append(parameters, ir.NewParam("this", paramType))
+	}
+
+	for _, birparam := range birSignature.parameters {
+		err, paramType := this.convertType(birparam.paramType)
+		if err != nil {
+			return err, nil, nil
+		}
+
+		parameters = append(parameters, ir.NewParam(birparam.name, paramType))
+	}
+
+	return nil, returnType, parameters
+}
+
+func (this *Compiler) searchSymbol(symbolName string) (error, *Symbol) {
+	var foundSymbol *Symbol = nil
+
+	this.symbolTables.foreach(func(item *SymbolTable) (stop bool) {
+		val, ok := (*item)[symbolName]
+		if !ok {
+			return false
+		}
+
+		foundSymbol = val
+
+		return true
+	})
+
+	if foundSymbol == nil {
+		return fmt.Errorf("Symbol not declarated"), nil
+	}
+
+	return nil, foundSymbol
+}
+
+func (this *Compiler) convertType(birType *SymbolType) (error, types.Type) {
+	switch lowerType(birType) {
+	case LOWERED_INT:
+		return nil, types.I64
+	case LOWERED_FLOAT:
+		return nil, types.Double
+	case LOWERED_BOOL:
+		return nil, types.I8
+	case LOWERED_VOID:
+		return nil, types.Void
+	case LOWERED_STRING:
+		return nil, types.I8Ptr
+	case LOWERED_POINTER:
+		err, elementType := this.convertType(birType.element)
+		if err != nil {
+			return err, nil
+		}
+
+		return nil, types.NewPointer(elementType)
+	}
+
+	err, structType := this.structTypeOf(birType.symbol)
+	if err != nil {
+		return err, nil
+	}
+
+	return nil, types.NewPointer(structType)
+}
+
+// convertFields sets the fields of the ir type of a struct
+func (this *Compiler) convertFields(symbol *Symbol, structType *types.StructType) error {
+	for _, fieldType := range structFields(symbol) {
+		err, convertedType := this.convertType(fieldType)
+		if err != nil {
+			return err
+		}
+
+		structType.Fields = append(structType.Fields, convertedType)
+	}
+
+	return nil
+}
+
+func (this *Compiler) walkBinaryExpression(node *Node) (error, value.Value) {
+	if node.nodeType != NODE_BINARY_EXPRESSION {
+		return fmt.Errorf("Not binary expression"), nil
+	}
+
+	block := this.blocks.peek()
+
+	err, leftValue := this.walkExpression(node.left)
+	if err != nil {
+		return err, nil
+	}
+
+	err, rightValue := this.walkExpression(node.right)
+	if err != nil {
+		return err, nil
+	}
+
+	// pointer arithmetic moves by elements, not bytes
+	if node.left.symbolType.kind == TYPE_POINTER && node.right.symbolType.kind != TYPE_POINTER {
+		pointerType := leftValue.Type().(*types.PointerType)
+
+		if node.token.tokenType == TOKEN_MINUS {
+			rightValue = block.NewSub(constant.NewInt(types.I64, 0), rightValue)
+		}
+
+		return nil, block.NewGetElementPtr(pointerType.ElemType, leftValue, rightValue)
+	}
+
+	if node.token.tokenType == TOKEN_PLUS {
+		if leftValue.Type() == types.Double {
+			return nil, block.NewFAdd(leftValue, rightValue)
+		} else {
+			return nil, block.NewAdd(leftValue, rightValue)
+		}
+	}
+
+	if node.token.tokenType == TOKEN_MINUS {
+		if leftValue.Type() == types.Double {
+			return nil, block.NewFSub(leftValue, rightValue)
+		} else {
+			return nil, block.NewSub(leftValue, rightValue)
+		}
+	}
+
+	if node.token.tokenType == TOKEN_DIVIDE {
+		if leftValue.Type() == types.Double {
+			return nil, block.NewFDiv(leftValue, rightValue)
+		} else {
+			return nil, block.NewSDiv(leftValue, rightValue)
+		}
+	}
+
+	if node.token.tokenType == TOKEN_MULTIPLY {
+		if leftValue.Type() == types.Double {
+			return nil, block.NewFMul(leftValue, rightValue)
+		} else {
+			return nil, block.NewMul(leftValue, rightValue)
+		}
+	}
+
+	if node.token.tokenType == TOKEN_AND {
+		return nil, block.NewAnd(leftValue, rightValue)
+	}
+
+	if node.token.tokenType == TOKEN_OR {
+		return nil, block.NewOr(leftValue, rightValue)
+	}
+
+	if node.token.tokenType == TOKEN_EQUAL {
+		if leftValue.Type() == types.Double {
+			return nil, block.NewFCmp(enum.FPredOEQ, leftValue, rightValue)
+		} else {
+			return nil, block.NewICmp(enum.IPredEQ, leftValue, rightValue)
+		}
+	}
+
+	if node.token.tokenType == TOKEN_DIFFERENT {
+		if leftValue.Type() == types.Double {
+			return nil, block.NewFCmp(enum.FPredONE, leftValue, rightValue)
+		} else {
+			return nil, block.NewICmp(enum.IPredNE, leftValue, rightValue)
+		}
+	}
+
+	if node.token.tokenType == TOKEN_GREATER {
+		if leftValue.Type() == types.Double {
+			return nil, block.NewFCmp(enum.FPredOGT, leftValue, rightValue)
+		} else {
+			return nil, block.NewICmp(enum.IPredSGT, leftValue, rightValue)
+		}
+	}
+
+	if node.token.tokenType == TOKEN_GREATER_EQUAL {
+		if leftValue.Type() == types.Double {
+			return nil, block.NewFCmp(enum.FPredOGE, leftValue, rightValue)
+		} else {
+			return nil, block.NewICmp(enum.IPredSGE, leftValue, rightValue)
+		}
+	}
+
+	if node.token.tokenType == TOKEN_LESS {
+		if leftValue.Type() == types.Double {
+			return nil, block.NewFCmp(enum.FPredOLT, leftValue, rightValue)
+		} else {
+			return nil, block.NewICmp(enum.IPredSLT, leftValue, rightValue)
+		}
+	}
+
+	if node.token.tokenType == TOKEN_LESS_EQUAL {
+		if leftValue.Type() == types.Double {
+			return nil, block.NewFCmp(enum.FPredOLE, leftValue, rightValue)
+		} else {
+			return nil, block.NewICmp(enum.IPredSLE, leftValue, rightValue)
+		}
+	}
+
+	return fmt.Errorf("invalid operation"), nil
+}
+
+func (this *Compiler) walkCast(node *Node) (error, value.Value) {
+	block := this.blocks.peek()
+
+	err, castValue := this.walkExpression(node.left)
+	if err != nil {
+		return err, nil
+	}
+
+	err, castType := this.convertType(node.symbolType)
+	if err != nil {
+		return err, nil
+	}
+
+	fromType := node.left.symbolType
+
+	if fromType.kind == TYPE_POINTER && node.symbolType.kind == TYPE_POINTER {
+		return nil, block.NewBitCast(castValue, castType)
+	}
+
+	if fromType.kind == TYPE_POINTER {
+		return nil, block.NewPtrToInt(castValue, castType)
+	}
+
+	if node.symbolType.kind == TYPE_POINTER {
+		return nil, block.NewIntToPtr(castValue, castType)
+	}
+
+	if fromType.name == "int" && node.symbolType.name == "float" {
+		return nil, block.NewSIToFP(castValue, castType)
+	}
+
+	if fromType.name == "float" && node.symbolType.name == "int" {
+		return nil, block.NewFPToSI(castValue, castType)
+	}
+
+	return nil, castValue
+}
+
+func (this *Compiler) walkLvalue(node *Node) (error, value.Value) {
+	if node.nodeType == NODE_VARIABLE {
+		symbol := node.symbol
+
+		// variable is in other module
+		if node.symbol != nil && node.symbol.simbolType.kind == TYPE_MODULE {
+			return nil, nil
+		}
+
+		if symbol.structType != nil {
+			block := this.blocks.peek()
+
+			allocated := block.NewAlloca(symbol.structType)
+			
+			this.currentInstance = allocated
+			this.constructor = true
+
+			// call init function
+			initFunc, ok := (*symbol.node.symbolTable)["init"]
+			if ok {
+				return this.functionValue(initFunc)
+			}
+
+			return nil, nil
+		}
+
+		return nil, symbol.value
+	}
+
+	if node.nodeType == NODE_MEMBER_ACCESS {
+		err, value := this.walkExpression(node.left)
+		if err != nil {
+			return err, nil
+		}
+
+		// member of an imported module
+		if value == nil && node.symbol.simbolType.kind == TYPE_FUNCTION {
+			return this.functionValue(node.symbol)
+		}
+
+		if value == nil && node.symbol.simbolType.kind == TYPE_STRUCT {
+			err, structType := this.structTypeOf(node.symbol)
+			if err != nil {
+				return err, nil
+			}
+
+			block := this.blocks.peek()
+
+			allocated := block.NewAlloca(structType)
+			
+			this.currentInstance = allocated
+			this.constructor = true
+
+			// call init function
+			initFunc, ok := (*node.symbol.node.symbolTable)["init"]
+			if ok {
+				return this.functionValue(initFunc)
+			}
+
+			return nil, nil
+		}
+
+		if node.symbol != nil && node.symbol.simbolType.kind == TYPE_MODULE {
+			return nil, value
+		}
+
+		// member access from struct
+		if _, ok := value.Type().(*types.PointerType); ok {
+			symbol := node.left.symbolType.symbol
+
+			fieldSymbol, ok := (*symbol.node.symbolTable)[node.token.tokenValue]
+			if !ok {
+				return fmt.Errorf("can't find member"), nil
+			}
+
+			if fieldSymbol.simbolType.kind == TYPE_FUNCTION {
+				this.currentInstance = value
+
+				return this.functionValue(fieldSymbol)
+			} else {
+				block := this.blocks.peek()
+
+				index := 0
+				for fieldNode := symbol.node.right; fieldNode != nil; fieldNode = fieldNode.next {
+					if fieldNode == fieldSymbol.node {
+						break
+					}
+
+					index++
+				}
+
+				indexValue := constant.NewInt(types.I32, int64(index))
+				zeroValue := constant.NewInt(types.I32, 0)
+
+				err, structType := this.structTypeOf(symbol)
+				if err != nil {
+					return err, nil
+				}
+
+				return nil, block.NewGetElementPtr(structType, value, zeroValue, indexValue)
+			}
+		}
+		
+		return nil, value
+	}
+
+	// the pointer is the address of the value pointed to
+	if node.nodeType == NODE_DEREFERENCE {
+		return this.walkExpression(node.left)
+	}
+
+	return fmt.Errorf("can't eval lvalue expression"), nil
+}
+
+func (this *Compiler) walkExpression(node *Node) (error, value.Value) {
+	block := this.blocks.peek()
+
+	if node.nodeType == NODE_INT {
+		intValue, err := strconv.ParseInt(node.token.tokenValue, 10, 64)
+		if err != nil {
+			return err, nil
+		}
+
+		return nil, constant.NewInt(types.I64, intValue)
+	}
+
+	if node.nodeType == NODE_FLOAT {
+		floatValue, err := strconv.ParseFloat(node.token.tokenValue, 64)
+		if err != nil {
+			return err, nil
+		}
+
+		return nil, constant.NewFloat(types.Double, floatValue)
+	}
+
+	if node.nodeType == NODE_STRING {
+		text := constant.NewCharArrayFromString(node.token.tokenValue + "\x00")
+
+		global := this.irModule.NewGlobalDef("", text)
+		global.Immutable = true
+		global.Linkage = enum.LinkagePrivate
+
+		zeroValue := constant.NewInt(types.I32, 0)
+
+		return nil, constant.NewGetElementPtr(text.Typ, global, zeroValue, zeroValue)
+	}
+
+	if node.nodeType == NODE_BOOL {
+		if node.token.tokenType == TOKEN_TRUE {
+			return nil, constant.NewBool(true)
+		} else {
+			return nil, constant.NewBool(false)
+		}
+	}
+
+	if node.nodeType == NODE_BINARY_EXPRESSION {
+		return this.walkBinaryExpression(node)
+	}
+
+	if node.nodeType == NODE_ADDRESS {
+		return this.walkLvalue(node.left)
+	}
+
+	if node.nodeType == NODE_CAST {
+		return this.walkCast(node)
+	}
+
+	if node.nodeType == NODE_CALL {
+		err, funcValue := this.walkLvalue(node.left)
+		if err != nil {
+			return err, nil
+		}
+
+		currentInstance := this.currentInstance
+		this.currentInstance = nil
+		
+		isConstructor := this.constructor
+		this.constructor = false
+
+		if isConstructor && funcValue == nil {
+			return nil, currentInstance
+		}
+
+		var arguments []value.Value
+
+		if currentInstance != nil {
+			arguments = append(arguments, currentInstance)
+		}
+
+		for argument := node.right.right; argument != nil; argument = argument.next {
+			err, argumentValue := this.walkExpression(argument)
+			if err != nil {
+				return err, nil
+			}
+
+			arguments = append(arguments, argumentValue)
+		}
+
+		call := block.NewCall(funcValue, arguments...)
+
+		if isConstructor {
+			return nil, currentInstance
+		}
+
+		return nil, call
+	}
+
+	if node.nodeType == NODE_VARIABLE_DECLARATION {
+		var initValue value.Value = nil
+		if node.right != nil {
+			var err error
+			err, initValue = this.walkExpression(node.right)
+			if err != nil {
+				return err, nil
+			}
+		}
+
+		err, irType := this.convertType(&node.symbol.simbolType)
+		if err != nil {
+			return err, nil
+		}
+
+		block := this.blocks.peek()
+		allocationValue := block.NewAlloca(irType)
+		allocationValue.SetName(node.symbol.name)
+
+		node.symbol.value = allocationValue
+
+		if initValue != nil {
+			block.NewStore(initValue, allocationValue)
+		}
+
+		return nil, block.NewLoad(allocationValue.ElemType, allocationValue)
+	}
+
+	err, expressionValue := this.walkLvalue(node)
+	if err != nil {
+		return err, nil
+	}
+
+	// parameters that are not stored hold their value, pointers included
+	if _, ok := expressionValue.(*ir.Param); ok && node.nodeType == NODE_VARIABLE {
+		return nil, expressionValue
+	}
+
+	if expressionValue != nil {
+		if pointerType, ok := expressionValue.Type().(*types.PointerType); ok {
+			// for structs return pointer
+			if _, ok := pointerType.ElemType.(*types.StructType); ok {
+				return nil, expressionValue;
+			}
+
+			expressionValue = block.NewLoad(pointerType.ElemType, expressionValue)
+		}
+	}
+
+	return nil, expressionValue
+}
+
+func (this *Compiler) walk(node *Node) error {
+	for node != nil {
+		if node.nodeType == NODE_RETURN {
+			var returnValue value.Value
+			if node.left != nil {
+				var err error
+				err, returnValue = this.walkExpression(node.left)
+				if err != nil {
+					return err
+				}
+			}
+
+			block := this.blocks.pop()
+
+			block.NewRet(returnValue)
+		} else if node.nodeType == NODE_ASSIGNMENT {
+			err, assignmentSource := this.walkLvalue(node.left)
+			if err != nil {
+				return err
+			}
+
+			err, assignmentValue := this.walkExpression(node.right)
+			if err != nil {
+				return err
+			}
+
+			block := this.blocks.peek()
+
+			block.NewStore(assignmentValue, assignmentSource)
+		} else if node.nodeType == NODE_IF {
+			err, ifExpression := this.walkExpression(node.left)
+			if err != nil {
+				return err
+			}
+
+			thenBlock := this.currentFunction.NewBlock("")
+			elseBlock := this.currentFunction.NewBlock("")
+			exitBlock := this.currentFunction.NewBlock("")
+
+			this.symbolTables.push(node.symbolTable)
+
+			this.symbolTables.push(node.right.left.symbolTable)
+			this.blocks.push(thenBlock)
+
+			err = this.walk(node.right.left)
+			if err != nil {
+				return err
+			}
+
+			if thenBlock.Term == nil {
+				this.blocks.pop()
+				thenBlock.NewBr(exitBlock)
+			}
+			this.symbolTables.pop()
+
+			this.blocks.push(elseBlock)
+
+			if node.right.right != nil {
+				this.symbolTables.push(node.right.right.symbolTable)
+
+				err = this.walk(node.right.right)
+				if err != nil {
+					return err
+				}
+
+				this.symbolTables.pop()
+			}
+
+			if elseBlock.Term == nil {
+				this.blocks.pop()
+				elseBlock.NewBr(exitBlock)
+			}
+
+			block := this.blocks.pop()
+			block.NewCondBr(ifExpression, thenBlock, elseBlock)
+
+			this.symbolTables.pop()
+
+			this.blocks.push(exitBlock)
+		} else if node.nodeType == NODE_UNSAFE {
+			this.symbolTables.push(node.symbolTable)
+
+			err := this.walk(node.left)
+			if err != nil {
+				return err
+			}
+
+			this.symbolTables.pop()
+		} else {
+			err, _ := this.walkExpression(node)
+			if err != nil {
+				return err
+			}
+		}
+
+		node = node.next
+	}
+
+	return nil
+}
+
+func (this *Compiler) walkRoot(node *Node) error {
+	for node != nil {
+		if node.nodeType == NODE_IMPLEMENT {
+			err := this.walkRoot(node.right)
+			if err != nil {
+				return err
+			}
+		} else if node.nodeType == NODE_FUNCTION || node.nodeType == NODE_CONSTRUCTOR {
+			this.symbolTables.push(node.left.symbolTable)
+
+			function := node.left.symbol.value.(*ir.Func)
+
+			this.currentFunction = function
+
+			block := function.NewBlock("")
+
+			// parameters that are assigned or have their address taken are stored like variables,
+			// the others are used as they are
+			stored := make(map[*Symbol]bool)
+			storedVariables(node.right, stored)
+
+			for _, birparam := range node.left.symbol.simbolType.signature.parameters {
+				if !stored[birparam.node.symbol] {
+					continue
+				}
+
+				parameter := birparam.node.symbol.value
+
+				allocationValue := block.NewAlloca(parameter.Type())
+				block.NewStore(parameter, allocationValue)
+
+				birparam.node.symbol.value = allocationValue
+			}
+
+			this.blocks.push(block)
+			err := this.walk(node.right)
+			if err != nil {
+				return err
+			}
+
+			if block.Term == nil {
+				block.NewRet(nil)
+			}
+
+			this.currentFunction = nil
+
+			this.symbolTables.pop()
+		}
+
+		node = node.next
+	}
+
+	return nil
+}
+
+// storedVariables collects the variables of the statements that are assigned or have their
+// address taken
+func storedVariables(node *Node, stored map[*Symbol]bool) {
+	for ; node != nil; node = node.next {
+		if (node.nodeType == NODE_ASSIGNMENT || node.nodeType == NODE_ADDRESS) && node.left != nil && node.left.nodeType == NODE_VARIABLE {
+			stored[node.left.symbol] = true
+		}
+
+		storedVariables(node.left, stored)
+		storedVariables(node.right, stored)
+	}
+}
+
+// mangleName prefixes a symbol with its module and struct. The name is a C identifier that can't
+// be the name of another symbol: the underscores of the names are written _0,
+// the dots of module paths _ and the struct is followed by __. The function f of the module a.b
+// is a_b_f, the method f of the struct b of the module a is a_b__f and the function b_f of the
+// module a is a_b_0f
+func mangleName(modulePath string, structName string, name string) string {
+	prefix := escapeName(modulePath) + "_"
+	if structName != "" {
+		prefix = prefix + escapeName(structName) + "__"
+	}
+
+	return prefix + escapeName(name)
+}
+
+func escapeName(name string) string {
+	return strings.NewReplacer("_", "_0", ".", "_").Replace(name)
+}
+
+func (this *Compiler) functionName(symbol *Symbol) string {
+	return objectName(symbol, this.exportC)
+}
+
+// objectName is the name of a function in the object files, extern functions keep the name
+// of the C function. When exporting to C, the exported functions keep their name too, methods
+// are prefixed by their struct: the method sum of Point is Point_sum
+func objectName(symbol *Symbol, exportC bool) string {
+	if symbol.linkName != "" {
+		return symbol.linkName
+	}
+
+	if symbol.name == "main" {
+		return symbol.name
+	}
+
+	structName := ""
+	if self := symbol.simbolType.signature.self; self != nil {
+		structName = self.name
+	}
+
+	if exportC && symbol.exported {
+		// the name is declared by the C headers
+		if structName == "" {
+			return cName(symbol.name)
+		}
+
+		return structName + "_" + symbol.name
+	}
+
+	return mangleName(*symbol.module, structName, symbol.name)
+}
+
+func (this *Compiler) createFunction(node *Node) (error, value.Value) {
+	symbol := node.symbol
+
+	err, returnType, signature := this.functionSignature(symbol.simbolType.signature)
+	if err != nil {
+		return err, nil
+	}
+
+	parameters := signature
+	if symbol.simbolType.signature.self != nil {
+		(*node.symbolTable)["this"].value = parameters[0]
+		parameters = parameters[1:]
+	}
+
+	for index, birparam := range symbol.simbolType.signature.parameters {
+		birparam.node.symbol.value = parameters[index]
+	}
+
+	function := this.irModule.NewFunc(
+		this.functionName(symbol),
+		returnType,
+		signature...,
+	)
+
+	// functions that are not exported can't be referenced by other modules
+	if !symbol.exported && symbol.name != "main" {
+		function.Linkage = enum.LinkageInternal
+	}
+
+	return nil, function
+}
+
+func (this *Compiler) walkRootDeclarations(node *Node) error {
+	for node != nil {
+		if node.nodeType == NODE_STRUCT {
+			err := this.convertFields(node.symbol, node.symbol.structType)
+			if err != nil {
+				return err
+			}
+		} else if node.nodeType == NODE_FUNCTION || node.nodeType == NODE_CONSTRUCTOR {
+			err, function := this.createFunction(node.left)
+			if err != nil {
+				return err
+			}
+
+			node.left.symbol.value = function
+		} else if node.nodeType == NODE_EXTERN {
+			err, declared := this.externs.add(node.left.symbol)
+			if err != nil {
+				return err
+			}
+
+			if declared {
+				node.left.symbol.value = this.declaredFunction(node.left.symbol.linkName)
+				node = node.next
+				continue
+			}
+
+			err, returnType, parameters := this.functionSignature(node.left.symbol.simbolType.signature)
+			if err != nil {
+				return err
+			}
+
+			function := this.irModule.NewFunc(this.functionName(node.left.symbol), returnType, parameters...)
+			function.CallingConv = enum.CallingConvC
+
+			node.left.symbol.value = function
+		} else if node.nodeType == NODE_IMPLEMENT {
+			err, found := this.searchSymbol(node.token.tokenValue)
+			if err != nil {
+				return err
+			}
+
+			this.currentStruct = found.structType
+
+			err = this.walkRootDeclarations(node.right)
+			if err != nil {
+				return err
+			}
+
+			this.currentStruct = nil
+		}
+
+		node = node.next
+	}
+
+	return nil
+}
+
+func (this *Compiler) walkRootTypes(node *Node) error {
+	for node != nil {
+		if node.nodeType == NODE_STRUCT {
+			structBody := types.NewStruct()
+			this.irModule.NewTypeDef(node.token.tokenValue, structBody)
+
+			node.symbol.structType = structBody
+		}
+
+		node = node.next
+	}
+
+	return nil
+}
+
+func (this *Compiler) Generate() error {
+	for _, ast := range this.asts {
+		this.symbolTables.push(ast.symbolTable)
+
+		err := this.walkRootTypes(ast.right)
+		if err != nil {
+			return err
+		}
+
+		this.symbolTables.pop()
+	}
+
+	// the files of a module share their declarations, so all of them are declared before the bodies
+	for _, ast := range this.asts {
+		this.symbolTables.push(ast.symbolTable)
+
+		err := this.walkRootDeclarations(ast.right)
+		if err != nil {
+			return err
+		}
+
+		this.symbolTables.pop()
+	}
+
+	for _, ast := range this.asts {
+		this.symbolTables.push(ast.symbolTable)
+
+		err := this.walkRoot(ast.right)
+		if err != nil {
+			return err
+		}
+
+		this.symbolTables.pop()
+	}
+
+	return nil
+}
+
+func (this *Compiler) runClang(outputFileName string, arguments ...string) error {
+	return runToolchain(this.toolchain, "ir", this.irModule.String(), this.output, outputFileName, arguments...)
+}
+
+func (this *Compiler) EmitAssembly(outputFileName string) error {
+	return this.runClang(outputFileName, append([]string{"-S"}, this.flags...)...)
+}
+
+// objectFlags are the clang flags used to produce objects, they are part of the cache key
+func (this *Compiler) objectFlags() []string {
+	return append([]string{"-c"}, this.flags...)
+}
+
+// cacheFlags is everything besides the sources that changes the objects
+func (this *Compiler) cacheFlags() []string {
+	return append([]string{BACKEND_LLVM, this.toolchain, this.irModule.TargetTriple, this.irModule.DataLayout, strconv.FormatBool(this.exportC)}, this.objectFlags()...)
+}
+
+func (this *Compiler) EmitObject(outputFileName string) error {
+	return this.runClang(outputFileName, this.objectFlags()...)
+}
+
+func (this *Compiler) Compile(outputFileName string) error {
+	err := this.Generate()
+	if err != nil {
+		return err
+	}
+
+	return this.EmitObject(outputFileName)
+}
@@ -0,0 +1,329 @@
+package compiler
+
+import (
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMangleName(t *testing.T) {
+	tests := []struct {
+		modulePath string
+		structName string
+		name       string
+		mangled    string
+	}{
+		{"a", "", "f", "a_f"},
+		{"a.b", "", "f", "a_b_f"},
+		{"a", "b", "f", "a_b__f"},
+		{"a_b", "", "f", "a_0b_f"},
+		{"a", "", "b_f", "a_b_0f"},
+		{"a", "", "_b", "a__0b"},
+		{"a_", "", "b", "a_0_b"},
+		{"a", "b", "_f", "a_b___0f"},
+		{"a.b", "", "_f", "a_b__0f"},
+	}
+
+	names := make(map[string]bool)
+	for _, test := range tests {
+		mangled := mangleName(test.modulePath, test.structName, test.name)
+		if mangled != test.mangled {
+			t.Errorf("%s %s %s: got %s, expected %s", test.modulePath, test.structName, test.name, mangled, test.mangled)
+		}
+
+		if names[mangled] {
+			t.Errorf("%s is the name of two symbols", mangled)
+		}
+		names[mangled] = true
+	}
+}
+
+// generateModule checks the sources and generates the code of a module with every backend
+func generateModule(t *testing.T, sources map[string]string, moduleName string) map[string]error {
+	t.Helper()
+
+	errs := make(map[string]error)
+	for _, backend := range backendKinds {
+		files := newMemoryFiles(sources)
+		err, graph := checkFiles(files, files.fileNames(), &BuildOptions{})
+		if err != nil {
+			t.Fatalf("check failed: %s", err)
+		}
+
+		errs[backend] = newBackend(graph.modules[moduleName], moduleName, &BuildOptions{Backend: backend}).Generate()
+	}
+
+	return errs
+}
+
+func TestExternSignatures(t *testing.T) {
+	sources := map[string]string{
+		"a.bir": "module a\nexport extern \"puts\" function print(text: string): int\n",
+		"main.bir": "module main\nimport a\nextern function puts(text: string): int\nfunction main(): int {\n\tputs(\"a\")\n\treturn a.print(\"b\")\n}\n",
+	}
+
+	for backend, err := range generateModule(t, sources, "main") {
+		if err != nil {
+			t.Errorf("%s: generate failed: %s", backend, err)
+		}
+	}
+
+	sources["main.bir"] = "module main\nimport a\nextern function puts(code: int): int\nfunction main(): int {\n\tputs(1)\n\treturn a.print(\"b\")\n}\n"
+
+	for backend, err := range generateModule(t, sources, "main") {
+		if err == nil || !strings.Contains(err.Error(), "Extern function puts is declared with another signature") {
+			t.Errorf("%s: expected a conflict of the extern signatures, got %v", backend, err)
+		}
+	}
+}
+
+// generateIR checks a module of one file and returns its llvm ir
+func generateIR(t *testing.T, text string) string {
+	t.Helper()
+
+	files := newMemoryFiles(map[string]string{"main.bir": text})
+	err, graph := checkFiles(files, files.fileNames(), &BuildOptions{})
+	if err != nil {
+		t.Fatalf("check failed: %s", err)
+	}
+
+	compiler := newCompiler(graph.modules["main"], "main", &BuildOptions{})
+	err = compiler.Generate()
+	if err != nil {
+		t.Fatalf("generate failed: %s", err)
+	}
+
+	return compiler.Code()
+}
+
+// verifyIR assembles the ir with llvm-as, the test goes on without it when llvm is not installed
+func verifyIR(t *testing.T, code string) {
+	t.Helper()
+
+	assembler, err := exec.LookPath("llvm-as")
+	if err != nil {
+		t.Log("llvm-as not found, the ir is not verified")
+		return
+	}
+
+	cmd := exec.Command(assembler, "-", "-o", "/dev/null")
+	cmd.Stdin = strings.NewReader(code)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("invalid ir: %s\n%s\n%s", err, output, code)
+	}
+}
+
+func TestStringLiterals(t *testing.T) {
+	code := generateIR(t, "module main\nextern function puts(text: string): int\nfunction greet(name: string): int {\n\treturn puts(name)\n}\nfunction main(): int {\n\tgreet(\"hello\")\n\treturn puts(\"a%b\")\n}\n")
+
+	for _, expected := range []string{
+		`private constant [6 x i8] c"hello\00"`,
+		`private constant [4 x i8] c"a%b\00"`,
+		`declare ccc i64 @puts(i8* %text)`,
+		`@main_greet(i8* %name)`,
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("%s not found in the ir:\n%s", expected, code)
+		}
+	}
+
+	verifyIR(t, code)
+}
+
+// runIR compiles the ir with llc and links it with the C compiler, it returns the exit status of
+// the program. The test is skipped when the tools are not installed
+func runIR(t *testing.T, code string) int {
+	t.Helper()
+
+	llc, err := exec.LookPath("llc")
+	if err != nil {
+		t.Skip("llc not found")
+	}
+
+	cc, err := exec.LookPath("cc")
+	if err != nil {
+		t.Skip("cc not found")
+	}
+
+	directory := t.TempDir()
+	assembly := filepath.Join(directory, "main.s")
+	program := filepath.Join(directory, "main")
+
+	cmd := exec.Command(llc, "-relocation-model=pic", "-o", assembly, "-")
+	cmd.Stdin = strings.NewReader(code)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("llc failed: %s\n%s", err, output)
+	}
+
+	output, err = exec.Command(cc, assembly, "-o", program).CombinedOutput()
+	if err != nil {
+		t.Fatalf("link failed: %s\n%s", err, output)
+	}
+
+	err = exec.Command(program).Run()
+
+	var exitError *exec.ExitError
+	if errors.As(err, &exitError) {
+		return exitError.ExitCode()
+	}
+
+	if err != nil {
+		t.Fatalf("run failed: %s", err)
+	}
+
+	return 0
+}
+
+const parametersSource = `module main
+
+struct Box {
+	value: int
+}
+
+implement Box {
+	init(value: int) {
+		this.value = value
+	}
+
+	function add(amount: int): int {
+		this.value = this.value + amount
+		return this.value
+	}
+}
+
+function decrement(n: int): int {
+	var first = n
+	n = n - 1
+	return first + n
+}
+
+function bump(target: ptr<int>, amount: int) {
+	unsafe {
+		target.* = target.* + amount
+	}
+}
+
+function addressed(x: int): int {
+	unsafe {
+		bump(&x, 5)
+	}
+
+	return x
+}
+
+function length(text: string): int {
+	return 2
+}
+
+function grow(box: Box, amount: int): int {
+	return box.add(amount)
+}
+
+function main(): int {
+	var box = Box(1)
+	return decrement(4) + addressed(2) + grow(box, 3) + length("hi")
+}
+`
+
+func TestParameters(t *testing.T) {
+	code := generateIR(t, parametersSource)
+
+	// only the parameters that are assigned or have their address taken are stored
+	for function, allocas := range map[string]int{
+		"main_decrement": 2,
+		"main_bump":      0,
+		"main_addressed": 1,
+		"main_length":    0,
+		"main_grow":      0,
+		"main_Box__add":  0,
+	} {
+		start := strings.Index(code, "@"+function+"(")
+		if start < 0 {
+			t.Errorf("%s not found in the ir:\n%s", function, code)
+			continue
+		}
+
+		body := code[start:]
+		body = body[:strings.Index(body, "\n}")]
+
+		if strings.Count(body, "alloca") != allocas {
+			t.Errorf("%s: expected %d allocas:\n%s", function, allocas, body)
+		}
+	}
+
+	verifyIR(t, code)
+
+	status := runIR(t, code)
+	if status != 20 {
+		t.Errorf("got exit status %d, expected 20", status)
+	}
+}
+
+func TestBoolLiterals(t *testing.T) {
+	tests := []struct {
+		condition string
+		branch    string
+		status    int
+	}{
+		{"true", "br i1 true", 1},
+		{"false", "br i1 false", 2},
+	}
+
+	for _, test := range tests {
+		code := generateIR(t, "module main\nfunction main(): int {\n\tif "+test.condition+" {\n\t\treturn 1\n\t}\n\n\treturn 2\n}\n")
+
+		if !strings.Contains(code, test.branch) {
+			t.Errorf("%s: %s not found in the ir:\n%s", test.condition, test.branch, code)
+		}
+
+		verifyIR(t, code)
+
+		status := runIR(t, code)
+		if status != test.status {
+			t.Errorf("%s: got exit status %d, expected %d", test.condition, status, test.status)
+		}
+	}
+}
+
+func TestFloatArithmetic(t *testing.T) {
+	code := generateIR(t, "module main\nfunction half(x: float): float {\n\treturn x / 2.0\n}\nfunction main(): int {\n\tvar x = half(1.5 + 2.25)\n\tif x > 1.5 {\n\t\treturn 1\n\t}\n\n\treturn 2\n}\n")
+
+	// floats are doubles, like the float type of the struct fields and of the parameters
+	for _, expected := range []string{"fdiv double %x, 2.0", "fadd double 1.5, 2.25", "fcmp ogt double"} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("%s not found in the ir:\n%s", expected, code)
+		}
+	}
+
+	verifyIR(t, code)
+
+	status := runIR(t, code)
+	if status != 1 {
+		t.Errorf("got exit status %d, expected 1", status)
+	}
+}
+
+func TestVoidReturn(t *testing.T) {
+	source := "module main\nfunction f() {\n\treturn\n}\nfunction main(): int {\n\tf()\n\treturn 3\n}\n"
+
+	for backend, err := range generateModule(t, map[string]string{"main.bir": source}, "main") {
+		if err != nil {
+			t.Errorf("%s: generate failed: %s", backend, err)
+		}
+	}
+
+	code := generateIR(t, source)
+
+	verifyIR(t, code)
+
+	status := runIR(t, code)
+	if status != 3 {
+		t.Errorf("got exit status %d, expected 3", status)
+	}
+}
@@ -0,0 +1,378 @@
+package compiler
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	SEVERITY_ERROR   = iota
+	SEVERITY_WARNING = iota
+	SEVERITY_NOTE    = iota
+)
+
+var severityStrings = []string{
+	"error",
+	"warning",
+	"note",
+}
+
+// DefaultErrorLimit is the number of errors reported by the command line before the others are
+// left out
+const DefaultErrorLimit = 20
+
+// Position is a place in a source, lines and columns start at 1
+type Position struct {
+	Offset int
+	Line   int
+	Column int
+}
+
+// Diagnostic is a problem found in the sources, the span goes from Start to End, End excluded.
+// Diagnostics are errors, the ones without a span have a zero Start
+type Diagnostic struct {
+	Severity int
+	File     string
+	Start    Position
+	End      Position
+	Message  string
+	Notes    []string
+	// line of the source the span starts on, empty when the source is not known
+	Source string
+}
+
+// Diagnostics are the problems found by a run, they are reported together
+type Diagnostics []*Diagnostic
+
+func (this Diagnostics) Error() string {
+	var messages []string
+	for _, diagnostic := range this {
+		messages = append(messages, diagnostic.Error())
+	}
+
+	return strings.Join(messages, "\n")
+}
+
+// asDiagnostics returns the diagnostics an error is made of, other errors are not diagnostics
+func asDiagnostics(err error) (Diagnostics, bool) {
+	var diagnostics Diagnostics
+	if errors.As(err, &diagnostics) {
+		return diagnostics, true
+	}
+
+	var diagnostic *Diagnostic
+	if errors.As(err, &diagnostic) {
+		return Diagnostics{diagnostic}, true
+	}
+
+	return nil, false
+}
+
+// toDiagnostics turns any error into diagnostics, the errors that are not diagnostics have no span
+func toDiagnostics(err error) Diagnostics {
+	if diagnostics, ok := asDiagnostics(err); ok {
+		return diagnostics
+	}
+
+	return Diagnostics{{Severity: SEVERITY_ERROR, Message: err.Error()}}
+}
+
+// mergeErrors joins the errors of several runs, a single error is kept as it is
+func mergeErrors(errs []error) error {
+	if len(errs) == 0 {
+		return nil
+	}
+
+	if len(errs) == 1 {
+		return errs[0]
+	}
+
+	var diagnostics Diagnostics
+	for _, err := range errs {
+		diagnostics = append(diagnostics, toDiagnostics(err)...)
+	}
+
+	return diagnostics
+}
+
+// sortDiagnostics orders diagnostics by file and by where they start
+func sortDiagnostics(diagnostics Diagnostics) {
+	sort.SliceStable(diagnostics, func(i int, j int) bool {
+		if diagnostics[i].File != diagnostics[j].File {
+			return diagnostics[i].File < diagnostics[j].File
+		}
+
+		return diagnostics[i].Start.Offset < diagnostics[j].Start.Offset
+	})
+}
+
+// limitErrors sorts the diagnostics of an error and keeps the first limit ones, a note tells how
+// many were left out. A limit of 0 keeps them all
+func limitErrors(err error, limit int) error {
+	diagnostics, ok := asDiagnostics(err)
+	if !ok || len(diagnostics) < 2 {
+		return err
+	}
+
+	diagnostics = append(Diagnostics{}, diagnostics...)
+	sortDiagnostics(diagnostics)
+
+	if limit <= 0 || len(diagnostics) <= limit {
+		return diagnostics
+	}
+
+	omitted := len(diagnostics) - limit
+	diagnostics = append(diagnostics[:limit], &Diagnostic{
+		Severity: SEVERITY_NOTE,
+		Message:  fmt.Sprintf("%d more errors not shown, the limit is %d", omitted, limit),
+	})
+
+	return diagnostics
+}
+
+// internalError reports a panic of the compiler, a bug of the compiler doesn't stop the program
+// embedding it
+func internalError(recovered any) *Diagnostic {
+	return &Diagnostic{Severity: SEVERITY_ERROR, Message: fmt.Sprintf("Internal compiler error: %v", recovered)}
+}
+
+func newDiagnostic(start Position, end Position, format string, arguments ...any) *Diagnostic {
+	return &Diagnostic{
+		Severity: SEVERITY_ERROR,
+		Start:    start,
+		End:      end,
+		Message:  fmt.Sprintf(format, arguments...),
+	}
+}
+
+func tokenStart(token *Token) Position {
+	return Position{Offset: token.position, Line: token.line, Column: token.column}
+}
+
+func tokenEnd(token *Token) Position {
+	return Position{Offset: token.endPosition, Line: token.endLine, Column: token.endColumn}
+}
+
+// tokenDiagnostic reports a problem with a token, the token can be nil
+func tokenDiagnostic(token *Token, format string, arguments ...any) *Diagnostic {
+	if token == nil {
+		return newDiagnostic(Position{}, Position{}, format, arguments...)
+	}
+
+	return newDiagnostic(tokenStart(token), tokenEnd(token), format, arguments...)
+}
+
+// nodeDiagnostic reports a problem with a node, the span covers the tokens of the node and of
+// its operands
+func nodeDiagnostic(node *Node, format string, arguments ...any) *Diagnostic {
+	var first *Token
+	var last *Token
+	nodeTokens(node, func(token *Token) {
+		if first == nil || token.position < first.position {
+			first = token
+		}
+
+		if last == nil || token.endPosition > last.endPosition {
+			last = token
+		}
+	})
+
+	if first == nil {
+		return newDiagnostic(Position{}, Position{}, format, arguments...)
+	}
+
+	return newDiagnostic(tokenStart(first), tokenEnd(last), format, arguments...)
+}
+
+// nodeTokens visits the tokens of a node and of its children, the next nodes are not visited
+func nodeTokens(node *Node, visit func(token *Token)) {
+	if node == nil {
+		return
+	}
+
+	if node.token != nil {
+		visit(node.token)
+	}
+
+	nodeTokens(node.left, visit)
+
+	for child := node.right; child != nil; child = child.next {
+		nodeTokens(child, visit)
+
+		// the arguments of a call are the only list that is part of an expression
+		if node.nodeType != NODE_LINK {
+			break
+		}
+	}
+}
+
+func (this *Diagnostic) note(format string, arguments ...any) *Diagnostic {
+	this.Notes = append(this.Notes, fmt.Sprintf(format, arguments...))
+
+	return this
+}
+
+func (this *Diagnostic) hasSpan() bool {
+	return this.Start.Line > 0
+}
+
+func (this *Diagnostic) location() string {
+	var location string
+	if this.hasSpan() {
+		location = fmt.Sprintf("%d:%d", this.Start.Line, this.Start.Column)
+	}
+
+	if this.File == "" {
+		return location
+	}
+
+	if location == "" {
+		return this.File
+	}
+
+	return this.File + ":" + location
+}
+
+func (this *Diagnostic) Error() string {
+	location := this.location()
+	if location == "" {
+		return this.Message
+	}
+
+	return location + ": " + this.Message
+}
+
+// Render formats the diagnostic with the source line it is about and the span underlined
+func (this *Diagnostic) Render() string {
+	var builder strings.Builder
+
+	fmt.Fprintf(&builder, "%s: %s\n", severityStrings[this.Severity], this.Message)
+
+	location := this.location()
+	if location != "" {
+		fmt.Fprintf(&builder, " --> %s\n", location)
+	}
+
+	margin := ""
+	if this.hasSpan() && this.Source != "" {
+		lineNumber := fmt.Sprintf("%d", this.Start.Line)
+		margin = strings.Repeat(" ", len(lineNumber))
+
+		// the underline stops at the end of the line of the start
+		width := len(this.Source) - (this.Start.Column - 1)
+		if this.End.Line == this.Start.Line && this.End.Column > this.Start.Column {
+			width = min(width, this.End.Column-this.Start.Column)
+		}
+		width = max(width, 1)
+
+		// tabs are kept under the underline, so it lines up with the source
+		var indentation strings.Builder
+		for index := 0; index < this.Start.Column-1 && index < len(this.Source); index++ {
+			if this.Source[index] == '\t' {
+				indentation.WriteByte('\t')
+			} else {
+				indentation.WriteByte(' ')
+			}
+		}
+
+		fmt.Fprintf(&builder, "%s |\n", margin)
+		fmt.Fprintf(&builder, "%s | %s\n", lineNumber, this.Source)
+		fmt.Fprintf(&builder, "%s | %s%s\n", margin, indentation.String(), strings.Repeat("^", width))
+	}
+
+	for _, note := range this.Notes {
+		fmt.Fprintf(&builder, "%s = note: %s\n", margin, note)
+	}
+
+	return builder.String()
+}
+
+// sourceLine returns the line of the text a diagnostic starts on
+func sourceLine(text string, line int) string {
+	lines := strings.Split(text, "\n")
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+
+	return strings.TrimRight(lines[line-1], "\r")
+}
+
+// inFile records the file the diagnostics of an error come from, text is the source of the file
+// or empty when it is not at hand, other errors are prefixed by the file name
+func inFile(err error, fileName string, text string) error {
+	diagnostics, ok := asDiagnostics(err)
+	if !ok {
+		return fmt.Errorf("%s: %w", fileName, err)
+	}
+
+	for _, diagnostic := range diagnostics {
+		if diagnostic.File == "" {
+			diagnostic.File = fileName
+		}
+	}
+
+	return withText(err, text)
+}
+
+// inSymbolFile records the file a symbol is declared in, the backends only know files through symbols
+func inSymbolFile(err error, symbol *Symbol) error {
+	if symbol == nil || symbol.fileName == nil {
+		return err
+	}
+
+	return inFile(err, *symbol.fileName, "")
+}
+
+// withText fills the source lines of diagnostics from the text they were found in
+func withText(err error, text string) error {
+	diagnostics, _ := asDiagnostics(err)
+	for _, diagnostic := range diagnostics {
+		if text != "" && diagnostic.Source == "" {
+			diagnostic.Source = sourceLine(text, diagnostic.Start.Line)
+		}
+	}
+
+	return err
+}
+
+// withSource fills the source lines of diagnostics reported without them, reading their files
+func withSource(err error, files fileSystem) error {
+	diagnostics, _ := asDiagnostics(err)
+	for _, diagnostic := range diagnostics {
+		if diagnostic.File == "" || diagnostic.Source != "" || !diagnostic.hasSpan() {
+			continue
+		}
+
+		// the positions of decoded asts are the ones of the sources they were made from
+		if filepath.Ext(diagnostic.File) == ".json" || isInterfaceFile(diagnostic.File) {
+			continue
+		}
+
+		data, readErr := files.readFile(diagnostic.File)
+		if readErr != nil {
+			continue
+		}
+
+		diagnostic.Source = sourceLine(string(data), diagnostic.Start.Line)
+	}
+
+	return err
+}
+
+// FormatError formats an error for the user, diagnostics are rendered with their source
+func FormatError(err error) string {
+	diagnostics, ok := asDiagnostics(err)
+	if !ok {
+		return fmt.Sprintf("error: %s", err)
+	}
+
+	var rendered []string
+	for _, diagnostic := range diagnostics {
+		rendered = append(rendered, strings.TrimRight(diagnostic.Render(), "\n"))
+	}
+
+	return strings.Join(rendered, "\n\n")
+}
@@ -0,0 +1,449 @@
+package compiler
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+func parseFile(files fileSystem, fileName string) (error, *Node) {
+	data, err := files.readFile(fileName)
+	if err != nil {
+		return err, nil
+	}
+
+	// ASTs serialized by emit and module interfaces are accepted in place of sources
+	if filepath.Ext(fileName) == ".json" || isInterfaceFile(fileName) {
+		err, root := decodeAST(data)
+		if err != nil {
+			return inFile(err, fileName, ""), nil
+		}
+
+		return nil, root
+	}
+
+	text := string(data)
+
+	lexer := newLexer(text)
+	parser := newParser(lexer)
+
+	err, root := parser.Parse()
+	if err != nil {
+		return inFile(err, fileName, text), root
+	}
+
+	return nil, root
+}
+
+// loadSources parses the given sources, or the entry module when there are none, and every
+// module they import
+func loadSources(files fileSystem, fileNames []string, options *BuildOptions) (error, *ModuleResolver) {
+	sourceRoots := append([]string{}, options.SourceRoots...)
+	for _, fileName := range fileNames {
+		directory := filepath.Dir(fileName)
+		if findString(sourceRoots, directory) < 0 {
+			sourceRoots = append(sourceRoots, directory)
+		}
+	}
+
+	resolver := newModuleResolver(files, sourceRoots)
+	for _, fileName := range fileNames {
+		err, _ := resolver.addFile(fileName)
+		if err != nil {
+			return err, nil
+		}
+	}
+
+	if len(fileNames) == 0 {
+		err, found := resolver.addModule(options.Entry)
+		if err != nil {
+			return err, nil
+		}
+
+		if !found {
+			return fmt.Errorf("Entry module not found: %s, searched in: %s", options.Entry, strings.Join(sourceRoots, ", ")), nil
+		}
+	}
+
+	err := resolver.resolve()
+	if err != nil {
+		return err, nil
+	}
+
+	return nil, resolver
+}
+
+func checkFiles(files fileSystem, fileNames []string, options *BuildOptions) (error, *ModuleGraph) {
+	err, resolver := loadSources(files, fileNames, options)
+	if err != nil {
+		return limitErrors(err, options.ErrorLimit), nil
+	}
+
+	graph := newModuleGraph(resolver.roots, resolver.fileNames)
+
+	err = graph.sort()
+	if err != nil {
+		return mergeErrors(append(resolver.parseErrors, err)), nil
+	}
+
+	// the modules that parsed are checked even when other files have errors, so their errors are
+	// reported together
+	errs := resolver.parseErrors
+
+	err = checkModules(graph, resolver.failedModules, options.Jobs)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	err = mergeErrors(errs)
+	if err == nil && options.ExportC {
+		err = exportedNames(graph)
+	}
+
+	if err != nil {
+		return limitErrors(withSource(err, files), options.ErrorLimit), nil
+	}
+
+	return nil, graph
+}
+
+// Check parses and checks the given sources, or the entry module when there are none, and every
+// module they import
+func Check(fileNames []string, options *BuildOptions) error {
+	err, _ := checkFiles(diskFiles{}, fileNames, options)
+
+	return err
+}
+
+// checkModules checks every module after the modules it imports, so their symbols are known
+// when the importers are checked, modules that don't depend on each other are checked concurrently.
+// The failed modules have files that don't parse, they are skipped with the modules importing them.
+func checkModules(graph *ModuleGraph, failedModules map[string]bool, jobs int) error {
+	return graph.forEachModule(jobs, func(moduleName string) error {
+		if failedModules[moduleName] {
+			return errSkipped
+		}
+
+		// a module with no file that parsed is not in the graph, so its importers are skipped here
+		for _, importName := range graph.imports[moduleName] {
+			if failedModules[importName] {
+				return errSkipped
+			}
+		}
+
+		asts := graph.modules[moduleName]
+
+		var fileNames []string
+		for _, ast := range asts {
+			fileNames = append(fileNames, graph.fileNames[ast])
+		}
+
+		checker := newChecker(asts, fileNames, graph.modules)
+
+		return checker.Check()
+	})
+}
+
+// Build compiles the sources and links them into the executable or library named by the options
+func Build(fileNames []string, options *BuildOptions) error {
+	err, graph := checkFiles(diskFiles{}, fileNames, options)
+	if err != nil {
+		return err
+	}
+
+	linker := newLinker(graph, options)
+
+	return withSource(linker.Link(), diskFiles{})
+}
+
+const (
+	EMIT_TOKENS = iota
+	EMIT_AST    = iota
+	EMIT_IR     = iota
+	EMIT_ASM    = iota
+	EMIT_OBJ    = iota
+	EMIT_EXE    = iota
+)
+
+var EmitStages = []string{
+	"tokens",
+	"ast",
+	"ir",
+	"asm",
+	"obj",
+	"exe",
+}
+
+func ParseEmitStage(stage string) (error, int) {
+	for index, emitString := range EmitStages {
+		if emitString == stage {
+			return nil, index
+		}
+	}
+
+	return fmt.Errorf("Invalid emit stage: %s, expected one of: %s", stage, strings.Join(EmitStages, ", ")), 0
+}
+
+// outputPath returns where an artifact is written: "-" for stdout, the output itself when it names a
+// single artifact, or the default name inside the output directory when several artifacts are produced.
+func outputPath(output string, defaultName string, artifactsCount int) (error, string) {
+	if output == "-" {
+		return nil, output
+	}
+
+	if output == "" {
+		return nil, defaultName
+	}
+
+	if artifactsCount == 1 {
+		return nil, output
+	}
+
+	err := os.MkdirAll(output, 0755)
+	if err != nil {
+		return err, ""
+	}
+
+	return nil, filepath.Join(output, defaultName)
+}
+
+func writeOutput(path string, data []byte, stdout io.Writer) error {
+	if path == "-" {
+		_, err := stdout.Write(data)
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
+
+func artifactName(fileName string, extension string) string {
+	baseName := filepath.Base(fileName)
+
+	return strings.TrimSuffix(baseName, filepath.Ext(baseName)) + extension
+}
+
+// projectFiles returns the given sources, or every source of the project when there are none
+func projectFiles(fileNames []string, options *BuildOptions) (error, []string) {
+	if len(fileNames) > 0 {
+		return nil, fileNames
+	}
+
+	err, resolver := loadSources(diskFiles{}, fileNames, options)
+	if err != nil {
+		return err, nil
+	}
+
+	return nil, resolver.fileNames
+}
+
+func emitTokens(fileNames []string, output string, jsonFormat bool, stdout io.Writer) error {
+	for _, fileName := range fileNames {
+		text, err := os.ReadFile(fileName)
+		if err != nil {
+			return err
+		}
+
+		err, tokens := newLexer(string(text)).tokenize()
+		if err != nil {
+			return inFile(err, fileName, string(text))
+		}
+
+		var data []byte
+		extension := ".tokens"
+		if jsonFormat {
+			err, data = encodeJSON(tokens)
+			if err != nil {
+				return err
+			}
+
+			extension = ".tokens.json"
+		} else {
+			var builder strings.Builder
+			for _, token := range tokens {
+				fmt.Fprintf(&builder, "%d:%d %s\n", token.line, token.column, token.toString())
+			}
+
+			data = []byte(builder.String())
+		}
+
+		err, path := outputPath(output, artifactName(fileName, extension), len(fileNames))
+		if err != nil {
+			return err
+		}
+
+		err = writeOutput(path, data, stdout)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func emitAST(fileNames []string, output string, jsonFormat bool, stdout io.Writer) error {
+	for _, fileName := range fileNames {
+		err, root := parseFile(diskFiles{}, fileName)
+		if err != nil {
+			return err
+		}
+
+		var data []byte
+		extension := ".ast"
+		if jsonFormat {
+			err, data = encodeJSON(root)
+			if err != nil {
+				return err
+			}
+
+			extension = ".ast.json"
+		} else {
+			var builder strings.Builder
+			root.Dump(&builder, 0, &[]int{}, "")
+
+			data = []byte(builder.String())
+		}
+
+		err, path := outputPath(output, artifactName(fileName, extension), len(fileNames))
+		if err != nil {
+			return err
+		}
+
+		err = writeOutput(path, data, stdout)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func emitModules(fileNames []string, stage int, output string, options *BuildOptions) error {
+	err, graph := checkFiles(diskFiles{}, fileNames, options)
+	if err != nil {
+		return err
+	}
+
+	linker := newLinker(graph, options)
+	for _, compiler := range linker.compilers {
+		err := compiler.Generate()
+		if err != nil {
+			return withSource(err, diskFiles{})
+		}
+
+		extension := compiler.extension()
+		if stage == EMIT_ASM {
+			extension = ".s"
+		} else if stage == EMIT_OBJ {
+			extension = ".obj"
+		}
+
+		err, path := outputPath(output, compiler.module()+extension, len(linker.compilers))
+		if err != nil {
+			return err
+		}
+
+		if stage == EMIT_ASM {
+			err = compiler.EmitAssembly(path)
+		} else if stage == EMIT_OBJ {
+			err = compiler.EmitObject(path)
+		} else {
+			err = writeOutput(path, []byte(compiler.Code()), options.stdout())
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Emit writes the artifacts of stage, one of the EMIT_ constants, to output
+func Emit(fileNames []string, stage int, output string, jsonFormat bool, options *BuildOptions) error {
+	if stage == EMIT_TOKENS || stage == EMIT_AST {
+		err, files := projectFiles(fileNames, options)
+		if err != nil {
+			return err
+		}
+
+		fileNames = files
+	}
+
+	switch stage {
+	case EMIT_TOKENS:
+		return emitTokens(fileNames, output, jsonFormat, options.stdout())
+	case EMIT_AST:
+		return emitAST(fileNames, output, jsonFormat, options.stdout())
+	case EMIT_EXE:
+		if output != "" {
+			options.ProgramName = output
+		}
+
+		return Build(fileNames, options)
+	}
+
+	return emitModules(fileNames, stage, output, options)
+}
+
+// Interpret checks the sources and executes them with the interpreter, the exit code of the
+// program is returned
+func Interpret(fileNames []string, options *BuildOptions) (error, int) {
+	err, graph := checkFiles(diskFiles{}, fileNames, options)
+	if err != nil {
+		return err, 0
+	}
+
+	interpreter := newInterpreter(graph, options.stdout())
+
+	err, exitCode := interpreter.Run(options.Entry)
+	if err != nil {
+		return withSource(err, diskFiles{}), 0
+	}
+
+	return nil, exitCode
+}
+
+// Run builds the sources to a temporary location and executes them, the exit code of the program
+// is returned
+func Run(fileNames []string, programArguments []string, options *BuildOptions) (error, int) {
+	if options.Library != "" {
+		return fmt.Errorf("Can't run a %s library", options.Library), 0
+	}
+
+	if options.backend() == BACKEND_WASM {
+		return fmt.Errorf("Can't run wasm modules, they are loaded by a wasm host"), 0
+	}
+
+	temporaryDirectory, err := os.MkdirTemp("", "bir-run-")
+	if err != nil {
+		return err, 0
+	}
+	defer os.RemoveAll(temporaryDirectory)
+
+	options.ProgramName = filepath.Join(temporaryDirectory, "program")
+
+	err = Build(fileNames, options)
+	if err != nil {
+		return err, 0
+	}
+
+	cmd := exec.Command(options.ProgramName, programArguments...)
+
+	cmd.Stdin = options.Stdin
+	cmd.Stdout = options.stdout()
+	cmd.Stderr = options.stderr()
+
+	err = cmd.Run()
+	if err != nil {
+		if exitError, ok := err.(*exec.ExitError); ok {
+			return nil, exitError.ExitCode()
+		}
+
+		return err, 0
+	}
+
+	return nil, 0
+}
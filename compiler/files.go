@@ -0,0 +1,101 @@
+package compiler
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// fileSystem is where the sources and the modules they import are read from, the disk for the
+// command line and a map of sources for Compile
+type fileSystem interface {
+	readFile(fileName string) ([]byte, error)
+	isFile(fileName string) bool
+	glob(pattern string) []string
+	// key is the same for every name of a file
+	key(fileName string) (string, error)
+}
+
+type diskFiles struct{}
+
+func (this diskFiles) readFile(fileName string) ([]byte, error) {
+	return os.ReadFile(fileName)
+}
+
+func (this diskFiles) isFile(fileName string) bool {
+	info, err := os.Stat(fileName)
+
+	return err == nil && !info.IsDir()
+}
+
+func (this diskFiles) glob(pattern string) []string {
+	fileNames, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil
+	}
+
+	sort.Strings(fileNames)
+
+	return fileNames
+}
+
+func (this diskFiles) key(fileName string) (string, error) {
+	return filepath.Abs(fileName)
+}
+
+// memoryFiles holds sources by their cleaned file name
+type memoryFiles map[string]string
+
+func newMemoryFiles(sources map[string]string) memoryFiles {
+	files := make(memoryFiles)
+	for fileName, text := range sources {
+		files[filepath.Clean(fileName)] = text
+	}
+
+	return files
+}
+
+func (this memoryFiles) readFile(fileName string) ([]byte, error) {
+	text, ok := this[filepath.Clean(fileName)]
+	if !ok {
+		return nil, &os.PathError{Op: "open", Path: fileName, Err: os.ErrNotExist}
+	}
+
+	return []byte(text), nil
+}
+
+func (this memoryFiles) isFile(fileName string) bool {
+	_, ok := this[filepath.Clean(fileName)]
+
+	return ok
+}
+
+func (this memoryFiles) glob(pattern string) []string {
+	var fileNames []string
+	for fileName := range this {
+		matched, err := filepath.Match(filepath.Clean(pattern), fileName)
+		if err == nil && matched {
+			fileNames = append(fileNames, fileName)
+		}
+	}
+
+	sort.Strings(fileNames)
+
+	return fileNames
+}
+
+func (this memoryFiles) key(fileName string) (string, error) {
+	return filepath.Clean(fileName), nil
+}
+
+// fileNames returns every source, sorted so the modules are always loaded in the same order
+func (this memoryFiles) fileNames() []string {
+	var fileNames []string
+	for fileName := range this {
+		fileNames = append(fileNames, fileName)
+	}
+
+	sort.Strings(fileNames)
+
+	return fileNames
+}
@@ -0,0 +1,150 @@
+package compiler
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	MODULE_UNVISITED = iota
+	MODULE_VISITING  = iota
+	MODULE_VISITED   = iota
+)
+
+// ModuleGraph is the import graph of the modules of a build, order lists the modules after the
+// modules they import so they can be checked and compiled in that order
+type ModuleGraph struct {
+	modules   map[string][]*Node
+	fileNames map[*Node]string
+	imports   map[string][]string
+	order     []string
+}
+
+func newModuleGraph(roots []*Node, fileNames []string) *ModuleGraph {
+	graph := &ModuleGraph{
+		modules:   make(map[string][]*Node),
+		fileNames: make(map[*Node]string),
+		imports:   make(map[string][]string),
+	}
+
+	for index, root := range roots {
+		moduleName := astModuleName(root)
+		graph.modules[moduleName] = append(graph.modules[moduleName], root)
+		graph.fileNames[root] = fileNames[index]
+	}
+
+	for moduleName, asts := range graph.modules {
+		graph.imports[moduleName] = moduleImports(asts)
+	}
+
+	return graph
+}
+
+// isInterface tells if a module was loaded from its interface file instead of its sources
+func (this *ModuleGraph) isInterface(moduleName string) bool {
+	return isInterfaceFile(this.fileNames[this.modules[moduleName][0]])
+}
+
+func (this *ModuleGraph) moduleNames() []string {
+	var names []string
+	for moduleName := range this.modules {
+		names = append(names, moduleName)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// transitiveImports lists the modules a module imports directly or through other modules, sorted
+func (this *ModuleGraph) transitiveImports(moduleName string) []string {
+	var imports []string
+
+	var visit func(moduleName string)
+	visit = func(moduleName string) {
+		for _, importName := range this.imports[moduleName] {
+			if findString(imports, importName) >= 0 {
+				continue
+			}
+
+			imports = append(imports, importName)
+			visit(importName)
+		}
+	}
+
+	visit(moduleName)
+	sort.Strings(imports)
+
+	return imports
+}
+
+// importLocation finds where a module imports another one, for error messages
+func (this *ModuleGraph) importLocation(moduleName string, importName string) string {
+	for _, ast := range this.modules[moduleName] {
+		for imp := ast.left.right; imp != nil; imp = imp.next {
+			if imp.left.token.tokenValue == importName {
+				return fmt.Sprintf("%s, line: %d, column: %d", this.fileNames[ast], imp.left.token.line, imp.left.token.column)
+			}
+		}
+	}
+
+	return moduleName
+}
+
+func (this *ModuleGraph) cycleError(path []string) error {
+	cycle := strings.Join(path, " -> ")
+	location := this.importLocation(path[len(path)-2], path[len(path)-1])
+
+	return fmt.Errorf("Import cycle: %s, imported at %s", cycle, location)
+}
+
+// sort orders the modules topologically, modules and imports are visited by name so the order
+// doesn't depend on the order of the sources
+func (this *ModuleGraph) sort() error {
+	states := make(map[string]int)
+	var path []string
+
+	var visit func(moduleName string) error
+	visit = func(moduleName string) error {
+		switch states[moduleName] {
+		case MODULE_VISITED:
+			return nil
+		case MODULE_VISITING:
+			start := findString(path, moduleName)
+			cycle := append(append([]string{}, path[start:]...), moduleName)
+
+			return this.cycleError(cycle)
+		}
+
+		states[moduleName] = MODULE_VISITING
+		path = append(path, moduleName)
+
+		for _, importName := range this.imports[moduleName] {
+			// modules outside of the build have nothing to order
+			if _, ok := this.modules[importName]; !ok {
+				continue
+			}
+
+			err := visit(importName)
+			if err != nil {
+				return err
+			}
+		}
+
+		path = path[:len(path)-1]
+		states[moduleName] = MODULE_VISITED
+		this.order = append(this.order, moduleName)
+
+		return nil
+	}
+
+	for _, moduleName := range this.moduleNames() {
+		err := visit(moduleName)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
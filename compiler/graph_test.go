@@ -0,0 +1,98 @@
+package compiler
+
+import (
+	"reflect"
+	"testing"
+)
+
+// sortedGraph parses the sources in the order of the file names and orders their modules
+func sortedGraph(t *testing.T, sources map[string]string, fileNames []string) (error, *ModuleGraph) {
+	t.Helper()
+
+	var roots []*Node
+	for _, fileName := range fileNames {
+		roots = append(roots, parseText(t, sources[fileName]))
+	}
+
+	graph := newModuleGraph(roots, fileNames)
+
+	return graph.sort(), graph
+}
+
+func TestGraphOrder(t *testing.T) {
+	sources := map[string]string{
+		"main.bir": "module main\nimport b\nimport a\n",
+		"a.bir":    "module a\nimport c\n",
+		"b.bir":    "module b\nimport c\nimport libc\n",
+		"c.bir":    "module c\n",
+	}
+
+	orders := [][]string{
+		{"main.bir", "a.bir", "b.bir", "c.bir"},
+		{"c.bir", "b.bir", "a.bir", "main.bir"},
+		{"b.bir", "main.bir", "c.bir", "a.bir"},
+	}
+
+	expected := []string{"c", "a", "b", "main"}
+	for _, fileNames := range orders {
+		err, graph := sortedGraph(t, sources, fileNames)
+		if err != nil {
+			t.Fatalf("%v: sort failed: %s", fileNames, err)
+		}
+
+		if !reflect.DeepEqual(graph.order, expected) {
+			t.Errorf("%v: got order %v, expected %v", fileNames, graph.order, expected)
+		}
+	}
+}
+
+func TestGraphCycle(t *testing.T) {
+	tests := []struct {
+		name    string
+		sources map[string]string
+		error   string
+	}{
+		{
+			name: "two modules",
+			sources: map[string]string{
+				"a.bir": "module a\nimport b\n",
+				"b.bir": "module b\n\nimport a\n",
+			},
+			error: "Import cycle: a -> b -> a, imported at b.bir, line: 3, column: 8",
+		},
+		{
+			name: "module importing itself",
+			sources: map[string]string{
+				"a.bir": "module a\nimport a\n",
+			},
+			error: "Import cycle: a -> a, imported at a.bir, line: 2, column: 8",
+		},
+		{
+			name: "cycle below the first module",
+			sources: map[string]string{
+				"a.bir": "module a\nimport b\n",
+				"b.bir": "module b\nimport c\n",
+				"c.bir": "module c\nimport d\n",
+				"d.bir": "module d\nimport b\n",
+			},
+			error: "Import cycle: b -> c -> d -> b, imported at d.bir, line: 2, column: 8",
+		},
+	}
+
+	for _, test := range tests {
+		var fileNames []string
+		for fileName := range test.sources {
+			fileNames = append(fileNames, fileName)
+		}
+
+		err, _ := sortedGraph(t, test.sources, fileNames)
+		if err == nil {
+			t.Errorf("%s: sort succeeded", test.name)
+			continue
+		}
+
+		if err.Error() != test.error {
+			t.Errorf("%s: got error %q, expected %q", test.name, err.Error(), test.error)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package compiler
+
+import (
+	"fmt"
+	"strings"
+)
+
+// cStructName is the name of the C type of a struct, prefixed by its module like the functions
+// that are not exported
+func cStructName(symbol *Symbol) string {
+	return escapeName(*symbol.module) + "_" + escapeName(symbol.name)
+}
+
+// cType follows convertType, structs are only known through pointers
+func cType(symbolType *SymbolType) string {
+	if symbolType.kind == TYPE_POINTER {
+		return cType(symbolType.element) + "*"
+	}
+
+	switch symbolType.name {
+	case "int":
+		return "int64_t"
+	case "float":
+		return "double"
+	case "bool":
+		return "int8_t"
+	case "void":
+		return "void"
+	case "string":
+		return "const char*"
+	}
+
+	return cStructName(symbolType.symbol) + "*"
+}
+
+// headerWriter declares the structs as opaque types, C code only passes around the pointers the
+// exported functions return, so their layout can change without breaking it
+type headerWriter struct {
+	compiler     Backend
+	structs      []*Symbol
+	declarations []string
+}
+
+func (this *headerWriter) useType(symbolType *SymbolType) string {
+	if symbolType.kind == TYPE_POINTER {
+		return this.useType(symbolType.element) + "*"
+	}
+
+	if symbolType.kind != TYPE_STRUCT && symbolType.kind != TYPE_INTERFACE {
+		return cType(symbolType)
+	}
+
+	for _, symbol := range this.structs {
+		if symbol == symbolType.symbol {
+			return cType(symbolType)
+		}
+	}
+
+	this.structs = append(this.structs, symbolType.symbol)
+
+	return cType(symbolType)
+}
+
+func (this *headerWriter) addFunction(symbol *Symbol) {
+	signature := symbol.simbolType.signature
+
+	var parameters []string
+	if signature.self != nil {
+		parameters = append(parameters, this.useType(signature.self)+" self")
+	}
+
+	for _, parameter := range signature.parameters {
+		parameters = append(parameters, this.useType(parameter.paramType)+" "+cName(parameter.name))
+	}
+
+	if len(parameters) == 0 {
+		parameters = append(parameters, "void")
+	}
+
+	returnType := this.useType(signature.returnType)
+
+	this.declarations = append(this.declarations, fmt.Sprintf("%s %s(%s);", returnType, this.compiler.functionName(symbol), strings.Join(parameters, ", ")))
+}
+
+func (this *headerWriter) addDeclarations(node *Node) {
+	for ; node != nil; node = node.next {
+		if node.nodeType == NODE_STRUCT && node.exported {
+			this.useType(&node.symbol.simbolType)
+		} else if node.nodeType == NODE_IMPLEMENT && node.exported {
+			this.addDeclarations(node.right)
+		} else if (node.nodeType == NODE_FUNCTION || node.nodeType == NODE_CONSTRUCTOR) && node.exported {
+			this.addFunction(node.left.symbol)
+		}
+	}
+}
+
+func isExportedToC(symbol *Symbol) bool {
+	return symbol.exported && symbol.linkName == ""
+}
+
+// exportedNames reports the exported functions that get the name of another function in the
+// objects when exporting to C, they are not prefixed by their module then. The other names are
+// mangled or are the names of C functions, which several modules can declare
+func exportedNames(graph *ModuleGraph) error {
+	declared := make(map[string]*Symbol)
+	var diagnostics Diagnostics
+
+	var add func(node *Node)
+	add = func(node *Node) {
+		for ; node != nil; node = node.next {
+			if node.nodeType == NODE_IMPLEMENT {
+				add(node.right)
+				continue
+			}
+
+			if node.nodeType != NODE_FUNCTION && node.nodeType != NODE_CONSTRUCTOR && node.nodeType != NODE_EXTERN {
+				continue
+			}
+
+			symbol := node.left.symbol
+			name := objectName(symbol, true)
+
+			previous, ok := declared[name]
+			if !ok {
+				declared[name] = symbol
+				continue
+			}
+
+			if !isExportedToC(previous) && !isExportedToC(symbol) {
+				continue
+			}
+
+			diagnostic := nodeDiagnostic(symbol.node, "Function %s has the C name %s of another function", symbol.name, name)
+			if symbol.fileName != nil {
+				diagnostic.File = *symbol.fileName
+			}
+
+			if previous.fileName != nil && previous.node != nil && previous.node.token != nil {
+				diagnostic.note("previous declaration: %s:%d:%d", *previous.fileName, previous.node.token.line, previous.node.token.column)
+			}
+
+			diagnostics = append(diagnostics, diagnostic)
+		}
+	}
+
+	for _, moduleName := range graph.order {
+		for _, ast := range graph.modules[moduleName] {
+			add(ast.right)
+		}
+	}
+
+	if len(diagnostics) > 0 {
+		return diagnostics
+	}
+
+	return nil
+}
+
+// moduleHeader returns a C header declaring the exported structs and functions of the module of
+// the compiler
+func moduleHeader(compiler Backend) string {
+	writer := &headerWriter{
+		compiler: compiler,
+	}
+
+	for _, ast := range compiler.sources() {
+		writer.addDeclarations(ast.right)
+	}
+
+	guard := "BIR_" + escapeName(compiler.module()) + "_H"
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "/* generated by bir from module %s, do not edit */\n\n", compiler.module())
+	fmt.Fprintf(&builder, "#ifndef %s\n#define %s\n\n", guard, guard)
+	builder.WriteString("#include <stdint.h>\n\n")
+	builder.WriteString("#ifdef __cplusplus\nextern \"C\" {\n#endif\n\n")
+
+	for _, symbol := range writer.structs {
+		fmt.Fprintf(&builder, "typedef struct %s %s;\n", cStructName(symbol), cStructName(symbol))
+	}
+
+	if len(writer.structs) > 0 {
+		builder.WriteString("\n")
+	}
+
+	for _, declaration := range writer.declarations {
+		builder.WriteString(declaration + "\n")
+	}
+
+	builder.WriteString("\n#ifdef __cplusplus\n}\n#endif\n\n")
+	fmt.Fprintf(&builder, "#endif /* %s */\n", guard)
+
+	return builder.String()
+}
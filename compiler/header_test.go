@@ -0,0 +1,90 @@
+package compiler
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestModuleHeader(t *testing.T) {
+	sources := map[string]string{
+		"shapes.bir": "module geo.shapes\nexport struct Point {\n\tx: int\n\tlong: float\n}\nexport implement Point {\n\tinit(x: int) {\n\t\tthis.x = x\n\t}\n\tfunction sum(): int {\n\t\treturn this.x\n\t}\n}\nexport function make_point(x: int): Point {\n\treturn Point(x)\n}\nexport function scale(point: Point, long: int): int {\n\treturn point.x * long\n}\nfunction hidden() {\n}\n",
+	}
+
+	files := newMemoryFiles(sources)
+	for _, backend := range backendKinds {
+		options := &BuildOptions{Backend: backend, ExportC: true}
+		err, graph := checkFiles(files, files.fileNames(), options)
+		if err != nil {
+			t.Fatalf("check failed: %s", err)
+		}
+
+		header := moduleHeader(newBackend(graph.modules["geo.shapes"], "geo.shapes", options))
+
+		for _, expected := range []string{
+			"typedef struct geo_shapes_Point geo_shapes_Point;",
+			"void Point_init(geo_shapes_Point* self, int64_t x);",
+			"int64_t Point_sum(geo_shapes_Point* self);",
+			"geo_shapes_Point* make_point(int64_t x);",
+			"int64_t scale(geo_shapes_Point* point, int64_t long_);",
+		} {
+			if !strings.Contains(header, expected) {
+				t.Errorf("%s: %q not found in the header:\n%s", backend, expected, header)
+			}
+		}
+
+		if strings.Contains(header, "hidden") {
+			t.Errorf("%s: functions that are not exported are declared:\n%s", backend, header)
+		}
+
+		// structs are opaque, their layout is not part of the header
+		if strings.Contains(header, "struct geo_shapes_Point {") {
+			t.Errorf("%s: the fields of a struct are declared:\n%s", backend, header)
+		}
+
+		// the functions are defined with the names the header declares
+		code := generateCode(t, newBackend(graph.modules["geo.shapes"], "geo.shapes", options))
+		for _, name := range []string{"Point_init", "Point_sum", "make_point", "scale"} {
+			if !strings.Contains(code, name+"(") && !strings.Contains(code, "$"+name+" ") {
+				t.Errorf("%s: %s not found in the code:\n%s", backend, name, code)
+			}
+		}
+	}
+}
+
+// generateCode generates the code of a backend, it fails the test on errors
+func generateCode(t *testing.T, backend Backend) string {
+	t.Helper()
+
+	err := backend.Generate()
+	if err != nil {
+		t.Fatalf("generate failed: %s", err)
+	}
+
+	return backend.Code()
+}
+
+func TestExportedNames(t *testing.T) {
+	sources := map[string]string{
+		"a.bir":    "module a\nexport function area(): int {\n\treturn 1\n}\nfunction main(): int {\n\treturn 0\n}\n",
+		"b.bir":    "module b\nexport function area(): int {\n\treturn 2\n}\nextern function puts(text: string): int\n",
+		"main.bir": "module main\nimport a\nimport b\nextern function puts(text: string): int\nfunction main(): int {\n\treturn a.area() + b.area()\n}\n",
+	}
+
+	// without exporting to C the names are prefixed by their module
+	files := newMemoryFiles(sources)
+	err, _ := checkFiles(files, files.fileNames(), &BuildOptions{})
+	if err != nil {
+		t.Fatalf("check failed: %s", err)
+	}
+
+	err, _ = checkFiles(files, files.fileNames(), &BuildOptions{ExportC: true})
+	if err == nil {
+		t.Fatalf("two functions named area are exported to C")
+	}
+
+	// the extern declarations of puts and the functions named main don't clash
+	diagnostics := toDiagnostics(err)
+	if len(diagnostics) != 1 || diagnostics[0].Error() != "b.bir:2:17: Function area has the C name area of another function" {
+		t.Errorf("expected a clash of the functions named area, got %v", err)
+	}
+}
@@ -0,0 +1,122 @@
+package compiler
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// module interface files hold the declarations other modules can use, they are asts encoded as
+// json with the bodies of the functions left out
+const interfaceExtension = ".biri"
+
+func isInterfaceFile(fileName string) bool {
+	return filepath.Ext(fileName) == interfaceExtension
+}
+
+// interfaceObjectPath is the object shipped together with an interface file
+func interfaceObjectPath(fileName string) string {
+	return strings.TrimSuffix(fileName, interfaceExtension) + ".obj"
+}
+
+type nodeChain struct {
+	first *Node
+	last  *Node
+}
+
+func (this *nodeChain) append(node *Node) {
+	node.next = nil
+
+	if this.first == nil {
+		this.first = node
+	} else {
+		this.last.next = node
+	}
+
+	this.last = node
+}
+
+// functionInterface turns a function into the declaration of its signature
+func functionInterface(node *Node) *Node {
+	declaration := *node.left
+	declaration.exported = node.exported
+
+	return &declaration
+}
+
+func declarationsInterface(node *Node) *Node {
+	declarations := &nodeChain{}
+	for ; node != nil; node = node.next {
+		switch node.nodeType {
+		case NODE_STRUCT, NODE_INTERFACE:
+			// structs that are not exported are kept for the layout of the ones that are
+			declaration := *node
+			declarations.append(&declaration)
+		case NODE_IMPLEMENT:
+			if !node.exported {
+				continue
+			}
+
+			declaration := *node
+			declaration.right = declarationsInterface(node.right)
+			declarations.append(&declaration)
+		case NODE_FUNCTION, NODE_CONSTRUCTOR:
+			if node.exported {
+				declarations.append(functionInterface(node))
+			}
+		case NODE_EXTERN:
+			if node.exported {
+				declaration := *node
+				declarations.append(&declaration)
+			}
+		case NODE_VARIABLE_DECLARATION:
+			// constants
+			if node.exported {
+				declaration := *node
+				declarations.append(&declaration)
+			}
+		}
+	}
+
+	return declarations.first
+}
+
+// moduleInterface builds the interface of the module declared by the given files
+func moduleInterface(asts []*Node) *Node {
+	imports := &nodeChain{}
+	declarations := &nodeChain{}
+
+	var importNames []string
+	for _, ast := range asts {
+		for imp := ast.left.right; imp != nil; imp = imp.next {
+			importName := imp.left.token.tokenValue
+			if imp.token != nil {
+				importName = importName + " as " + imp.token.tokenValue
+			}
+
+			if findString(importNames, importName) >= 0 {
+				continue
+			}
+
+			importNames = append(importNames, importName)
+
+			declaration := *imp
+			imports.append(&declaration)
+		}
+
+		for declaration := declarationsInterface(ast.right); declaration != nil; {
+			next := declaration.next
+			declarations.append(declaration)
+			declaration = next
+		}
+	}
+
+	return &Node{
+		nodeType: NODE_PROGRAM,
+		left: &Node{
+			nodeType: NODE_LINK,
+			left:     asts[0].left.left,
+			right:    imports.first,
+		},
+		right: declarations.first,
+	}
+}
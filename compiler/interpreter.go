@@ -0,0 +1,617 @@
+package compiler
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// deeper calls are reported instead of overflowing the stack of the interpreter
+const maxCallDepth = 10000
+
+// Instance is a struct value of the interpreter, structs are shared like the pointers the compiler
+// generates for them
+type Instance struct {
+	symbol *Symbol
+	fields map[string]any
+}
+
+// Frame holds the variables of a function call
+type Frame struct {
+	variables   map[*Symbol]any
+	returnValue any
+}
+
+// Interpreter executes checked modules directly from their ast, it follows the semantics of the
+// code the compiler generates: ints are 64 bit, structs are references and and/or evaluate both
+// of their operands
+type Interpreter struct {
+	graph *ModuleGraph
+	// function nodes by their declaration, the node symbols point to
+	functions map[*Node]*Node
+	// root variables of every module
+	globals map[*Symbol]any
+	frames  *Stack[*Frame]
+	output  io.Writer
+}
+
+func newInterpreter(graph *ModuleGraph, output io.Writer) *Interpreter {
+	return &Interpreter{
+		graph:     graph,
+		functions: make(map[*Node]*Node),
+		globals:   make(map[*Symbol]any),
+		frames:    &Stack[*Frame]{},
+		output:    output,
+	}
+}
+
+func (this *Interpreter) addFunctions(node *Node) {
+	for ; node != nil; node = node.next {
+		if node.nodeType == NODE_IMPLEMENT {
+			this.addFunctions(node.right)
+		} else if node.nodeType == NODE_FUNCTION || node.nodeType == NODE_CONSTRUCTOR {
+			this.functions[node.left] = node
+		}
+	}
+}
+
+func (this *Interpreter) zeroValue(symbolType *SymbolType) any {
+	switch symbolType.name {
+	case "int":
+		return int64(0)
+	case "float":
+		return float64(0)
+	case "bool":
+		return false
+	case "string":
+		return ""
+	}
+
+	return nil
+}
+
+func (this *Interpreter) variable(symbol *Symbol) (any, bool) {
+	if this.frames.len() > 0 {
+		if value, ok := this.frames.peek().variables[symbol]; ok {
+			return value, true
+		}
+	}
+
+	value, ok := this.globals[symbol]
+
+	return value, ok
+}
+
+func (this *Interpreter) setVariable(symbol *Symbol, value any) {
+	if _, ok := this.globals[symbol]; ok || this.frames.len() == 0 {
+		this.globals[symbol] = value
+		return
+	}
+
+	this.frames.peek().variables[symbol] = value
+}
+
+func (this *Interpreter) instance(node *Node) (error, *Instance) {
+	err, value := this.evaluate(node)
+	if err != nil {
+		return err, nil
+	}
+
+	instance, ok := value.(*Instance)
+	if !ok || instance == nil {
+		return nodeDiagnostic(node, "Member access on an uninitialized struct"), nil
+	}
+
+	return nil, instance
+}
+
+func (this *Interpreter) construct(symbol *Symbol, argumentsNode *Node) (error, any) {
+	instance := &Instance{
+		symbol: symbol,
+		fields: make(map[string]any),
+	}
+
+	for field := symbol.node.right; field != nil; field = field.next {
+		if field.symbol == nil {
+			continue
+		}
+
+		instance.fields[field.token.tokenValue] = this.zeroValue(&field.symbol.simbolType)
+
+		if field.right != nil {
+			err, value := this.evaluate(field.right)
+			if err != nil {
+				return err, nil
+			}
+
+			instance.fields[field.token.tokenValue] = value
+		}
+	}
+
+	if initSymbol, ok := (*symbol.node.symbolTable)["init"]; ok {
+		err, _ := this.call(initSymbol, instance, argumentsNode)
+		if err != nil {
+			return err, nil
+		}
+	}
+
+	return nil, instance
+}
+
+func (this *Interpreter) call(symbol *Symbol, self *Instance, argumentsNode *Node) (error, any) {
+	var arguments []any
+	for argument := argumentsNode.right; argument != nil; argument = argument.next {
+		err, value := this.evaluate(argument)
+		if err != nil {
+			return err, nil
+		}
+
+		arguments = append(arguments, value)
+	}
+
+	if symbol.linkName != "" {
+		return this.callExtern(symbol, arguments)
+	}
+
+	function, ok := this.functions[symbol.node]
+	if !ok {
+		return fmt.Errorf("Function %s has no body to interpret, it comes from an interface file", symbol.name), nil
+	}
+
+	if this.frames.len() >= maxCallDepth {
+		return fmt.Errorf("Stack overflow in %s", symbol.name), nil
+	}
+
+	frame := &Frame{
+		variables: make(map[*Symbol]any),
+	}
+
+	if self != nil {
+		frame.variables[(*symbol.node.symbolTable)["this"]] = self
+	}
+
+	for index, parameter := range symbol.simbolType.signature.parameters {
+		frame.variables[parameter.node.symbol] = arguments[index]
+	}
+
+	this.frames.push(frame)
+	defer this.frames.pop()
+
+	err, _ := this.execute(function.right)
+	if err != nil {
+		// the diagnostics of the body are in the file of the function, the innermost call sets it
+		if _, ok := asDiagnostics(err); ok && symbol.fileName != nil {
+			err = inFile(err, *symbol.fileName, "")
+		}
+
+		return err, nil
+	}
+
+	return nil, frame.returnValue
+}
+
+// callExtern runs the C functions the interpreter knows about
+func (this *Interpreter) callExtern(symbol *Symbol, arguments []any) (error, any) {
+	switch symbol.linkName {
+	case "puts":
+		_, err := fmt.Fprintln(this.output, arguments[0])
+		if err != nil {
+			return err, nil
+		}
+
+		return nil, int64(0)
+	case "putchar":
+		_, err := this.output.Write([]byte{byte(arguments[0].(int64))})
+		if err != nil {
+			return err, nil
+		}
+
+		return nil, arguments[0]
+	case "abs", "labs", "llabs":
+		value := arguments[0].(int64)
+		if value < 0 {
+			value = -value
+		}
+
+		return nil, value
+	}
+
+	return fmt.Errorf("Extern function %s can't be called by the interpreter", symbol.linkName), nil
+}
+
+// callee evaluates what is called, a function or a struct, and the instance of methods
+func (this *Interpreter) callee(node *Node) (error, *Symbol, *Instance) {
+	if node.nodeType == NODE_VARIABLE {
+		return nil, node.symbol, nil
+	}
+
+	if node.nodeType != NODE_MEMBER_ACCESS {
+		return nodeDiagnostic(node, "Only functions can be called"), nil, nil
+	}
+
+	// member of an imported module
+	if node.left.symbolType.kind == TYPE_MODULE {
+		return nil, node.symbol, nil
+	}
+
+	err, instance := this.instance(node.left)
+	if err != nil {
+		return err, nil, nil
+	}
+
+	// methods are looked up on the struct of the instance, so calls through interfaces work
+	method, ok := (*instance.symbol.node.symbolTable)[node.token.tokenValue]
+	if !ok || method.simbolType.kind != TYPE_FUNCTION {
+		return tokenDiagnostic(node.token, "Struct %s has no method %s", instance.symbol.name, node.token.tokenValue), nil, nil
+	}
+
+	return nil, method, instance
+}
+
+func (this *Interpreter) evaluateCall(node *Node) (error, any) {
+	err, symbol, instance := this.callee(node.left)
+	if err != nil {
+		return err, nil
+	}
+
+	var value any
+	if symbol.node != nil && symbol.node.nodeType == NODE_STRUCT {
+		err, value = this.construct(symbol, node.right)
+	} else {
+		err, value = this.call(symbol, instance, node.right)
+	}
+
+	// the errors of the call itself, like a stack overflow, are reported at the call
+	if _, ok := asDiagnostics(err); err != nil && !ok {
+		return nodeDiagnostic(node, "%s", err), nil
+	}
+
+	return err, value
+}
+
+func (this *Interpreter) evaluateBinary(node *Node) (error, any) {
+	err, left := this.evaluate(node.left)
+	if err != nil {
+		return err, nil
+	}
+
+	err, right := this.evaluate(node.right)
+	if err != nil {
+		return err, nil
+	}
+
+	switch leftValue := left.(type) {
+	case int64:
+		rightValue := right.(int64)
+
+		switch node.token.tokenType {
+		case TOKEN_PLUS:
+			return nil, leftValue + rightValue
+		case TOKEN_MINUS:
+			return nil, leftValue - rightValue
+		case TOKEN_MULTIPLY:
+			return nil, leftValue * rightValue
+		case TOKEN_DIVIDE:
+			if rightValue == 0 {
+				return nodeDiagnostic(node, "Division by zero"), nil
+			}
+
+			return nil, leftValue / rightValue
+		case TOKEN_LESS:
+			return nil, leftValue < rightValue
+		case TOKEN_LESS_EQUAL:
+			return nil, leftValue <= rightValue
+		case TOKEN_GREATER:
+			return nil, leftValue > rightValue
+		case TOKEN_GREATER_EQUAL:
+			return nil, leftValue >= rightValue
+		}
+	case float64:
+		rightValue := right.(float64)
+
+		switch node.token.tokenType {
+		case TOKEN_PLUS:
+			return nil, leftValue + rightValue
+		case TOKEN_MINUS:
+			return nil, leftValue - rightValue
+		case TOKEN_MULTIPLY:
+			return nil, leftValue * rightValue
+		case TOKEN_DIVIDE:
+			return nil, leftValue / rightValue
+		case TOKEN_LESS:
+			return nil, leftValue < rightValue
+		case TOKEN_LESS_EQUAL:
+			return nil, leftValue <= rightValue
+		case TOKEN_GREATER:
+			return nil, leftValue > rightValue
+		case TOKEN_GREATER_EQUAL:
+			return nil, leftValue >= rightValue
+		}
+	case bool:
+		rightValue := right.(bool)
+
+		switch node.token.tokenType {
+		case TOKEN_AND:
+			return nil, leftValue && rightValue
+		case TOKEN_OR:
+			return nil, leftValue || rightValue
+		}
+	}
+
+	switch node.token.tokenType {
+	case TOKEN_EQUAL:
+		return nil, left == right
+	case TOKEN_DIFFERENT:
+		return nil, left != right
+	}
+
+	return nodeDiagnostic(node, "Invalid operation"), nil
+}
+
+func (this *Interpreter) evaluateCast(node *Node) (error, any) {
+	err, value := this.evaluate(node.left)
+	if err != nil {
+		return err, nil
+	}
+
+	fromType := node.left.symbolType
+	toType := node.symbolType
+
+	if fromType.kind == TYPE_POINTER || toType.kind == TYPE_POINTER {
+		return nodeDiagnostic(node, "Raw pointers are not supported by the interpreter"), nil
+	}
+
+	if fromType.name == "int" && toType.name == "float" {
+		return nil, float64(value.(int64))
+	}
+
+	if fromType.name == "float" && toType.name == "int" {
+		return nil, int64(value.(float64))
+	}
+
+	return nil, value
+}
+
+func (this *Interpreter) evaluate(node *Node) (error, any) {
+	switch node.nodeType {
+	case NODE_INT:
+		value, err := strconv.ParseInt(node.token.tokenValue, 10, 64)
+		if err != nil {
+			return err, nil
+		}
+
+		return nil, value
+	case NODE_FLOAT:
+		value, err := strconv.ParseFloat(node.token.tokenValue, 64)
+		if err != nil {
+			return err, nil
+		}
+
+		return nil, value
+	case NODE_BOOL:
+		return nil, node.token.tokenType == TOKEN_TRUE
+	case NODE_STRING:
+		return nil, node.token.tokenValue
+	case NODE_VARIABLE:
+		value, ok := this.variable(node.symbol)
+		if !ok {
+			return nodeDiagnostic(node, "Variable %s has no value", node.symbol.name), nil
+		}
+
+		return nil, value
+	case NODE_VARIABLE_DECLARATION:
+		value := this.zeroValue(&node.symbol.simbolType)
+		if node.right != nil {
+			var err error
+			err, value = this.evaluate(node.right)
+			if err != nil {
+				return err, nil
+			}
+		}
+
+		if this.frames.len() == 0 {
+			this.globals[node.symbol] = value
+		} else {
+			this.frames.peek().variables[node.symbol] = value
+		}
+
+		return nil, value
+	case NODE_NOT:
+		err, value := this.evaluate(node.left)
+		if err != nil {
+			return err, nil
+		}
+
+		return nil, !value.(bool)
+	case NODE_BINARY_EXPRESSION:
+		return this.evaluateBinary(node)
+	case NODE_CAST:
+		return this.evaluateCast(node)
+	case NODE_CALL:
+		return this.evaluateCall(node)
+	case NODE_MEMBER_ACCESS:
+		// constant of an imported module
+		if node.left.symbolType.kind == TYPE_MODULE {
+			value, ok := this.variable(node.symbol)
+			if !ok {
+				return nodeDiagnostic(node, "Variable %s has no value", node.symbol.name), nil
+			}
+
+			return nil, value
+		}
+
+		err, instance := this.instance(node.left)
+		if err != nil {
+			return err, nil
+		}
+
+		return nil, instance.fields[node.token.tokenValue]
+	case NODE_ADDRESS, NODE_DEREFERENCE:
+		return nodeDiagnostic(node, "Raw pointers are not supported by the interpreter"), nil
+	}
+
+	return nodeDiagnostic(node, "Can't interpret %s", nodeStrings[node.nodeType]), nil
+}
+
+func (this *Interpreter) assign(node *Node, value any) error {
+	if node.nodeType == NODE_VARIABLE {
+		this.setVariable(node.symbol, value)
+		return nil
+	}
+
+	if node.nodeType == NODE_MEMBER_ACCESS && node.left.symbolType.kind != TYPE_MODULE {
+		err, instance := this.instance(node.left)
+		if err != nil {
+			return err
+		}
+
+		instance.fields[node.token.tokenValue] = value
+
+		return nil
+	}
+
+	if node.nodeType == NODE_DEREFERENCE {
+		return nodeDiagnostic(node, "Raw pointers are not supported by the interpreter")
+	}
+
+	return nodeDiagnostic(node, "Can't assign to %s", nodeStrings[node.nodeType])
+}
+
+// execute runs statements until one of them returns
+func (this *Interpreter) execute(node *Node) (error, bool) {
+	for ; node != nil; node = node.next {
+		switch node.nodeType {
+		case NODE_IF:
+			err, condition := this.evaluate(node.left)
+			if err != nil {
+				return err, false
+			}
+
+			branch := node.right.right
+			if condition.(bool) {
+				branch = node.right.left
+			}
+
+			err, returned := this.execute(branch)
+			if err != nil || returned {
+				return err, returned
+			}
+		case NODE_WHILE:
+			for {
+				err, condition := this.evaluate(node.left)
+				if err != nil {
+					return err, false
+				}
+
+				if !condition.(bool) {
+					break
+				}
+
+				err, returned := this.execute(node.right.left)
+				if err != nil || returned {
+					return err, returned
+				}
+			}
+		case NODE_UNSAFE:
+			err, returned := this.execute(node.left)
+			if err != nil || returned {
+				return err, returned
+			}
+		case NODE_ASSIGNMENT:
+			err, value := this.evaluate(node.right)
+			if err != nil {
+				return err, false
+			}
+
+			err = this.assign(node.left, value)
+			if err != nil {
+				return err, false
+			}
+		case NODE_RETURN:
+			if node.left != nil {
+				err, value := this.evaluate(node.left)
+				if err != nil {
+					return err, false
+				}
+
+				this.frames.peek().returnValue = value
+			}
+
+			return nil, true
+		default:
+			err, _ := this.evaluate(node)
+			if err != nil {
+				return err, false
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// findMain returns the main function of the entry module, or of the first module declaring one
+func (this *Interpreter) findMain(entry string) (error, *Symbol) {
+	for _, moduleName := range this.graph.order {
+		if entry != "" && moduleName != entry {
+			continue
+		}
+
+		symbol, ok := (*this.graph.modules[moduleName][0].symbolTable)["main"]
+		if ok && symbol.simbolType.kind == TYPE_FUNCTION {
+			return nil, symbol
+		}
+	}
+
+	if entry != "" {
+		return fmt.Errorf("No main function in entry module %s", entry), nil
+	}
+
+	return fmt.Errorf("No main function found"), nil
+}
+
+// Run initializes the root variables of every module, imported modules first, then calls main,
+// the value main returns is the exit code
+func (this *Interpreter) Run(entry string) (error, int) {
+	for _, moduleName := range this.graph.order {
+		for _, ast := range this.graph.modules[moduleName] {
+			this.addFunctions(ast.right)
+		}
+	}
+
+	for _, moduleName := range this.graph.order {
+		for _, ast := range this.graph.modules[moduleName] {
+			for node := ast.right; node != nil; node = node.next {
+				if node.nodeType != NODE_VARIABLE_DECLARATION {
+					continue
+				}
+
+				err, _ := this.evaluate(node)
+				if err != nil {
+					return inFile(err, this.graph.fileNames[ast], ""), 0
+				}
+			}
+		}
+	}
+
+	err, mainSymbol := this.findMain(entry)
+	if err != nil {
+		return err, 0
+	}
+
+	// nothing passes arguments to main, like in the programs the backends generate
+	if len(mainSymbol.simbolType.signature.parameters) > 0 {
+		return inFile(tokenDiagnostic(mainSymbol.node.token, "main can't have parameters"), *mainSymbol.fileName, ""), 0
+	}
+
+	err, value := this.call(mainSymbol, nil, &Node{nodeType: NODE_LINK})
+	if err != nil {
+		return err, 0
+	}
+
+	exitCode, ok := value.(int64)
+	if !ok {
+		return nil, 0
+	}
+
+	return nil, int(exitCode)
+}
@@ -0,0 +1,42 @@
+package compiler
+
+import "testing"
+
+// interpret checks the sources and runs their main module with the interpreter
+func interpret(t *testing.T, sources map[string]string) (error, int) {
+	t.Helper()
+
+	files := newMemoryFiles(sources)
+	err, graph := checkFiles(files, files.fileNames(), &BuildOptions{})
+	if err != nil {
+		t.Fatalf("check failed: %s", err)
+	}
+
+	return newInterpreter(graph, nil).Run("main")
+}
+
+func TestInterpreterErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		error string
+	}{
+		{"main parameters", "module main\nfunction main(x: int): int {\n\treturn x\n}\n", "main.bir:2:10: main can't have parameters"},
+		{"division by zero", "module main\nfunction div(a: int, b: int): int {\n\treturn a / b\n}\nfunction main(): int {\n\treturn div(1, 0)\n}\n", "main.bir:3:9: Division by zero"},
+		{"stack overflow", "module main\nfunction f(n: int): int {\n\treturn f(n + 1)\n}\nfunction main(): int {\n\treturn f(0)\n}\n", "main.bir:3:9: Stack overflow in f"},
+	}
+
+	for _, test := range tests {
+		err, _ := interpret(t, map[string]string{"main.bir": test.text})
+		if err == nil {
+			t.Errorf("%s: run succeeded", test.name)
+			continue
+		}
+
+		// runtime errors are diagnostics, with the file and the span of what failed
+		diagnostics, ok := asDiagnostics(err)
+		if !ok || len(diagnostics) != 1 || diagnostics[0].Error() != test.error {
+			t.Errorf("%s: got %v, expected %s", test.name, err, test.error)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package compiler
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+)
+
+var DefaultJobs = runtime.NumCPU()
+
+// errSkipped marks the modules that were not processed because a module they import failed
+var errSkipped = errors.New("skipped")
+
+// protect runs work and returns its panic as an error, the callers of the jobs can't recover
+// the panics of their goroutines
+func protect(work func() error) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = internalError(recovered)
+		}
+	}()
+
+	return work()
+}
+
+// runJobs runs work for every index, at most jobs at the same time. The error of the lowest index
+// is returned, so what is reported doesn't depend on the scheduling.
+func runJobs(jobs int, count int, work func(index int) error) error {
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	errs := make([]error, count)
+	semaphore := make(chan struct{}, jobs)
+
+	var group sync.WaitGroup
+	for index := 0; index < count; index++ {
+		group.Add(1)
+		semaphore <- struct{}{}
+
+		go func(index int) {
+			defer group.Done()
+			defer func() { <-semaphore }()
+
+			errs[index] = protect(func() error { return work(index) })
+		}(index)
+	}
+
+	group.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// forEachModule runs work for every module once the modules it imports are done, at most jobs
+// at the same time. Modules importing a module that failed are skipped and the errors of the
+// modules that failed are returned together, in order.
+func (this *ModuleGraph) forEachModule(jobs int, work func(moduleName string) error) error {
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	done := make(map[string]chan struct{})
+	for _, moduleName := range this.order {
+		done[moduleName] = make(chan struct{})
+	}
+
+	errs := make(map[string]error)
+	var errsMutex sync.Mutex
+
+	semaphore := make(chan struct{}, jobs)
+
+	var group sync.WaitGroup
+	for _, moduleName := range this.order {
+		group.Add(1)
+
+		go func(moduleName string) {
+			defer group.Done()
+			defer close(done[moduleName])
+
+			var err error
+			for _, importName := range this.imports[moduleName] {
+				importDone, ok := done[importName]
+				if !ok {
+					continue
+				}
+
+				<-importDone
+
+				errsMutex.Lock()
+				if errs[importName] != nil {
+					err = errSkipped
+				}
+				errsMutex.Unlock()
+			}
+
+			if err == nil {
+				semaphore <- struct{}{}
+				err = protect(func() error { return work(moduleName) })
+				<-semaphore
+			}
+
+			errsMutex.Lock()
+			errs[moduleName] = err
+			errsMutex.Unlock()
+		}(moduleName)
+	}
+
+	group.Wait()
+
+	var failures []error
+	for _, moduleName := range this.order {
+		if err := errs[moduleName]; err != nil && err != errSkipped {
+			failures = append(failures, err)
+		}
+	}
+
+	return mergeErrors(failures)
+}
@@ -0,0 +1,65 @@
+package compiler
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestRunJobsPanic(t *testing.T) {
+	err := runJobs(2, 3, func(index int) error {
+		if index == 1 {
+			panic("boom")
+		}
+
+		return nil
+	})
+
+	if err == nil || err.Error() != "Internal compiler error: boom" {
+		t.Fatalf("got %v, expected the panic as an error", err)
+	}
+
+	if _, ok := asDiagnostics(err); !ok {
+		t.Errorf("the panic is not reported as a diagnostic")
+	}
+}
+
+func TestForEachModulePanic(t *testing.T) {
+	sources := map[string]string{
+		"a.bir": "module a\n",
+		"b.bir": "module b\nimport a\n",
+		"c.bir": "module c\nimport b\n",
+		"d.bir": "module d\nimport a\n",
+	}
+
+	err, graph := sortedGraph(t, sources, []string{"a.bir", "b.bir", "c.bir", "d.bir"})
+	if err != nil {
+		t.Fatalf("sort failed: %s", err)
+	}
+
+	var visited []string
+	var visitedMutex sync.Mutex
+
+	err = graph.forEachModule(2, func(moduleName string) error {
+		visitedMutex.Lock()
+		visited = append(visited, moduleName)
+		visitedMutex.Unlock()
+
+		if moduleName == "b" {
+			panic("boom")
+		}
+
+		return nil
+	})
+
+	if err == nil || err.Error() != "Internal compiler error: boom" {
+		t.Fatalf("got %v, expected the panic as an error", err)
+	}
+
+	if findString(visited, "c") >= 0 {
+		t.Errorf("a module importing the module that panicked was processed")
+	}
+
+	if findString(visited, "d") < 0 {
+		t.Errorf("a module independent of the module that panicked was not processed")
+	}
+}
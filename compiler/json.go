@@ -0,0 +1,135 @@
+package compiler
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type jsonToken struct {
+	Type     string `json:"type"`
+	Value    string `json:"value,omitempty"`
+	Position int    `json:"position"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	End       int `json:"end"`
+	EndLine   int `json:"endLine"`
+	EndColumn int `json:"endColumn"`
+}
+
+type jsonNode struct {
+	Type  string `json:"type"`
+	Token *Token `json:"token,omitempty"`
+	Left  *Node  `json:"left,omitempty"`
+	Right *Node  `json:"right,omitempty"`
+	Next  *Node  `json:"next,omitempty"`
+
+	Exported bool `json:"exported,omitempty"`
+}
+
+func findString(values []string, value string) int {
+	for index, current := range values {
+		if current == value {
+			return index
+		}
+	}
+
+	return -1
+}
+
+func (this *Token) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonToken{
+		Type:     tokenTypesString[this.tokenType],
+		Value:    this.tokenValue,
+		Position: this.position,
+		Line:     this.line,
+		Column:   this.column,
+		End:       this.endPosition,
+		EndLine:   this.endLine,
+		EndColumn: this.endColumn,
+	})
+}
+
+func (this *Token) UnmarshalJSON(data []byte) error {
+	var decoded jsonToken
+	err := json.Unmarshal(data, &decoded)
+	if err != nil {
+		return err
+	}
+
+	tokenType := findString(tokenTypesString, decoded.Type)
+	if tokenType < 0 {
+		return fmt.Errorf("Invalid token type: %s", decoded.Type)
+	}
+
+	*this = Token{
+		tokenType:  tokenType,
+		tokenValue: decoded.Value,
+		position:   decoded.Position,
+		line:       decoded.Line,
+		column:     decoded.Column,
+		endPosition: decoded.End,
+		endLine:     decoded.EndLine,
+		endColumn:   decoded.EndColumn,
+	}
+
+	return nil
+}
+
+func (this *Node) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonNode{
+		Type:  nodeStrings[this.nodeType],
+		Token: this.token,
+		Left:  this.left,
+		Right: this.right,
+		Next:  this.next,
+
+		Exported: this.exported,
+	})
+}
+
+func (this *Node) UnmarshalJSON(data []byte) error {
+	var decoded jsonNode
+	err := json.Unmarshal(data, &decoded)
+	if err != nil {
+		return err
+	}
+
+	nodeType := findString(nodeStrings, decoded.Type)
+	if nodeType < 0 {
+		return fmt.Errorf("Invalid node type: %s", decoded.Type)
+	}
+
+	*this = Node{
+		nodeType: nodeType,
+		token:    decoded.Token,
+		left:     decoded.Left,
+		right:    decoded.Right,
+		next:     decoded.Next,
+		exported: decoded.Exported,
+	}
+
+	return nil
+}
+
+func encodeJSON(value any) (error, []byte) {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err, nil
+	}
+
+	return nil, append(data, '\n')
+}
+
+func decodeAST(data []byte) (error, *Node) {
+	var root Node
+	err := json.Unmarshal(data, &root)
+	if err != nil {
+		return err, nil
+	}
+
+	if root.nodeType != NODE_PROGRAM {
+		return fmt.Errorf("Invalid AST root: %s, expected: %s", nodeStrings[root.nodeType], nodeStrings[NODE_PROGRAM]), nil
+	}
+
+	return nil, &root
+}
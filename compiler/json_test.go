@@ -0,0 +1,132 @@
+package compiler
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+const jsonTestSource = `module main
+
+import shapes as s
+
+extern "labs" function abs(x: int): int
+
+export struct Counter {
+	count: int
+	ratio: float
+	next: ptr<int>
+}
+
+export implement Counter {
+	init() {
+		this.count = 0
+		this.ratio = 1.5
+	}
+
+	function add(amount: int) {
+		this.count = this.count + amount
+	}
+}
+
+function main(): int {
+	var c = Counter()
+	var i = 0
+	while i < 5 and not (i == 3) {
+		c.add(i)
+		i = i + 1
+	}
+
+	unsafe {
+		var q = &i
+		q.* = q.* + 1
+	}
+
+	if c.ratio > 1.0 {
+		return i as int
+	} else {
+		return abs(0 - 1)
+	}
+}
+`
+
+// parseText parses a source like parseFile does with a file
+func parseText(t *testing.T, text string) *Node {
+	t.Helper()
+
+	err, root := newParser(newLexer(text)).Parse()
+	if err != nil {
+		t.Fatalf("parse failed: %s", err)
+	}
+
+	return root
+}
+
+func TestASTRoundTrip(t *testing.T) {
+	root := parseText(t, jsonTestSource)
+
+	err, data := encodeJSON(root)
+	if err != nil {
+		t.Fatalf("encode failed: %s", err)
+	}
+
+	err, decoded := decodeAST(data)
+	if err != nil {
+		t.Fatalf("decode failed: %s", err)
+	}
+
+	if !reflect.DeepEqual(root, decoded) {
+		t.Fatalf("decoded ast differs from the parsed one")
+	}
+
+	err, encodedAgain := encodeJSON(decoded)
+	if err != nil {
+		t.Fatalf("encode of the decoded ast failed: %s", err)
+	}
+
+	if !bytes.Equal(data, encodedAgain) {
+		t.Fatalf("encoding of the decoded ast differs:\n%s\n%s", data, encodedAgain)
+	}
+}
+
+func TestTokensRoundTrip(t *testing.T) {
+	err, tokens := newLexer(jsonTestSource).tokenize()
+	if err != nil {
+		t.Fatalf("tokenize failed: %s", err)
+	}
+
+	err, data := encodeJSON(tokens)
+	if err != nil {
+		t.Fatalf("encode failed: %s", err)
+	}
+
+	var decoded []*Token
+	err = json.Unmarshal(data, &decoded)
+	if err != nil {
+		t.Fatalf("decode failed: %s", err)
+	}
+
+	if !reflect.DeepEqual(tokens, decoded) {
+		t.Fatalf("decoded tokens differ from the lexed ones")
+	}
+}
+
+func TestDecodeInvalidAST(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"unknown node type", `{"type": "NODE_NOTHING"}`},
+		{"unknown token type", `{"type": "NODE_PROGRAM", "token": {"type": "TOKEN_NOTHING"}}`},
+		{"not a program", `{"type": "NODE_INT"}`},
+		{"not json", `module main`},
+	}
+
+	for _, test := range tests {
+		err, _ := decodeAST([]byte(test.data))
+		if err == nil {
+			t.Errorf("%s: decode succeeded", test.name)
+		}
+	}
+}
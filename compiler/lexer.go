@@ -1,4 +1,4 @@
-package main
+package compiler
 
 import (
 	"fmt"
@@ -19,6 +19,8 @@ const (
 	TOKEN_WITH     = iota
 	TOKEN_CONST    = iota
 	TOKEN_EXPORT   = iota
+	TOKEN_EXTERN   = iota
+	TOKEN_UNSAFE   = iota
 
 	TOKEN_ASSIGN        = iota
 	TOKEN_AS            = iota
@@ -36,6 +38,7 @@ const (
 	TOKEN_MINUS    = iota
 	TOKEN_DIVIDE   = iota
 	TOKEN_MULTIPLY = iota
+	TOKEN_AMPERSAND = iota
 
 	TOKEN_ADD_ASSIGN       = iota
 	TOKEN_SUBSTRACT_ASSIGN = iota
@@ -53,6 +56,7 @@ const (
 	TOKEN_FLOAT  = iota
 	TOKEN_STRING = iota
 	TOKEN_BOOL   = iota
+	TOKEN_PTR    = iota
 
 	TOKEN_VAR       = iota
 	TOKEN_STRUCT    = iota
@@ -89,6 +93,8 @@ var tokenTypesString = []string{
 	"TOKEN_WITH",
 	"TOKEN_CONST",
 	"TOKEN_EXPORT",
+	"TOKEN_EXTERN",
+	"TOKEN_UNSAFE",
 
 	"TOKEN_ASSIGN",
 	"TOKEN_AS",
@@ -106,6 +112,7 @@ var tokenTypesString = []string{
 	"TOKEN_MINUS",
 	"TOKEN_DIVIDE",
 	"TOKEN_MULTIPLY",
+	"TOKEN_AMPERSAND",
 
 	"TOKEN_ADD_ASSIGN",
 	"TOKEN_SUBSTRACT_ASSIGN",
@@ -123,6 +130,7 @@ var tokenTypesString = []string{
 	"TOKEN_FLOAT",
 	"TOKEN_STRING",
 	"TOKEN_BOOL",
+	"TOKEN_PTR",
 
 	"TOKEN_VAR",
 	"TOKEN_STRUCT",
@@ -151,15 +159,22 @@ type Token struct {
 	position   int
 	line       int
 	column     int
+	// where the token ends, the character there is not part of it
+	endPosition int
+	endLine     int
+	endColumn   int
 }
 
 func (this *Lexer) newTokenWithValue(tokenType int, tokenValue string) *Token {
 	return &Token{
 		tokenType:  tokenType,
 		tokenValue: tokenValue,
-		position:   this.currentPosition,
-		line:       this.currentLine,
-		column:     this.currentColumn,
+		position:   this.tokenPosition,
+		line:       this.tokenLine,
+		column:     this.tokenColumn,
+		endPosition: this.currentPosition,
+		endLine:     this.currentLine,
+		endColumn:   this.currentColumn,
 	}
 }
 
@@ -181,6 +196,11 @@ type Lexer struct {
 	currentPosition int
 	currentLine     int
 	currentColumn   int
+
+	// where the token being scanned starts
+	tokenPosition int
+	tokenLine     int
+	tokenColumn   int
 }
 
 func newLexer(text string) *Lexer {
@@ -188,7 +208,7 @@ func newLexer(text string) *Lexer {
 		text:            text,
 		currentPosition: 0,
 		currentLine:     1,
-		currentColumn:   0,
+		currentColumn:   1,
 	}
 }
 
@@ -344,6 +364,8 @@ func (this *Lexer) getKeyword(text string) (error, *Token) {
 		return nil, this.newToken(TOKEN_STRING)
 	case "bool":
 		return nil, this.newToken(TOKEN_BOOL)
+	case "ptr":
+		return nil, this.newToken(TOKEN_PTR)
 	case "true":
 		return nil, this.newToken(TOKEN_TRUE)
 	case "false":
@@ -378,6 +400,10 @@ func (this *Lexer) getKeyword(text string) (error, *Token) {
 		return nil, this.newToken(TOKEN_CONST)
 	case "export":
 		return nil, this.newToken(TOKEN_EXPORT)
+	case "extern":
+		return nil, this.newToken(TOKEN_EXTERN)
+	case "unsafe":
+		return nil, this.newToken(TOKEN_UNSAFE)
 	}
 
 	return fmt.Errorf("Invalid keyword"), nil
@@ -415,18 +441,18 @@ func (this *Lexer) parseIdentifier() (error, *Token) {
 }
 
 func (this *Lexer) advance() error {
-	this.currentPosition += 1
-	if this.currentPosition >= len(this.text) {
-		return fmt.Errorf("End of file reached")
-	}
-
-	if this.text[this.currentPosition] == '\n' {
+	if this.currentPosition < len(this.text) && this.text[this.currentPosition] == '\n' {
 		this.currentLine += 1
-		this.currentColumn = 0
+		this.currentColumn = 1
 	} else {
 		this.currentColumn += 1
 	}
 
+	this.currentPosition += 1
+	if this.currentPosition >= len(this.text) {
+		return fmt.Errorf("End of file reached")
+	}
+
 	return nil
 }
 
@@ -478,7 +504,7 @@ func (this *Lexer) parseNumber() (error, *Token) {
 			}
 
 			commaFound = true
-			this.advance()
+			value = value + string(currentCharacter)
 
 			continue
 		}
@@ -487,7 +513,8 @@ func (this *Lexer) parseNumber() (error, *Token) {
 			break
 		}
 
-		if startWithZero {
+		// the decimals of 0.5 can follow the zero
+		if startWithZero && !commaFound {
 			return fmt.Errorf("Can't have multiple zeros at start of a number"), nil
 		}
 
@@ -521,16 +548,60 @@ func (this *Lexer) isIdentifierKeywordLetter(firstCharacter bool, character byte
 	return character >= '0' && character <= '9'
 }
 
+func (this *Lexer) markTokenStart() {
+	this.tokenPosition = this.currentPosition
+	this.tokenLine = this.currentLine
+	this.tokenColumn = this.currentColumn
+}
+
 func (this *Lexer) SimpleToken(token_type int) (error, *Token) {
 	this.advance()
 
 	return nil, this.newToken(token_type)
 }
 
+// next scans the next token, errors are reported on the characters scanned for the token, which
+// are skipped with the rest of the invalid word
 func (this *Lexer) next() (error, *Token) {
+	err, token := this.scan()
+	if err != nil {
+		this.skipInvalid()
+
+		start := Position{Offset: this.tokenPosition, Line: this.tokenLine, Column: this.tokenColumn}
+		end := Position{Offset: this.currentPosition, Line: this.currentLine, Column: this.currentColumn}
+		if end.Offset <= start.Offset {
+			end = Position{Offset: start.Offset + 1, Line: start.Line, Column: start.Column + 1}
+		}
+
+		return newDiagnostic(start, end, "%s", err), nil
+	}
+
+	return nil, token
+}
+
+// skipInvalid skips the rest of a token that has an error, with the letters, digits and dots that
+// follow it, so the next scan starts after the invalid word
+func (this *Lexer) skipInvalid() {
+	if this.currentPosition == this.tokenPosition {
+		this.advance()
+	}
+
+	for this.currentPosition < len(this.text) {
+		currentCharacter := this.text[this.currentPosition]
+		if currentCharacter != '.' && !this.isIdentifierKeywordLetter(false, currentCharacter) {
+			break
+		}
+
+		this.advance()
+	}
+}
+
+func (this *Lexer) scan() (error, *Token) {
 	var currentCharacter byte
 	for {
 		if this.currentPosition >= len(this.text) {
+			this.markTokenStart()
+
 			return nil, this.newToken(TOKEN_EOF)
 		}
 
@@ -542,6 +613,8 @@ func (this *Lexer) next() (error, *Token) {
 		this.advance()
 	}
 
+	this.markTokenStart()
+
 	switch currentCharacter {
 	case '+':
 		return this.parsePlus()
@@ -575,6 +648,8 @@ func (this *Lexer) next() (error, *Token) {
 		return this.SimpleToken(TOKEN_CLOSED_SQUARE)
 	case ',':
 		return this.SimpleToken(TOKEN_COMMA)
+	case '&':
+		return this.SimpleToken(TOKEN_AMPERSAND)
 	case '.':
 		return this.SimpleToken(TOKEN_DOT)
 	case '"':
@@ -591,3 +666,21 @@ func (this *Lexer) next() (error, *Token) {
 
 	return fmt.Errorf("Invalid token"), nil
 }
+
+func (this *Lexer) tokenize() (error, []*Token) {
+	var tokens []*Token
+	for {
+		err, token := this.next()
+		if err != nil {
+			return err, nil
+		}
+
+		tokens = append(tokens, token)
+
+		if token.tokenType == TOKEN_EOF {
+			break
+		}
+	}
+
+	return nil, tokens
+}
@@ -0,0 +1,39 @@
+package compiler
+
+import "testing"
+
+func TestNumbers(t *testing.T) {
+	tests := []struct {
+		text      string
+		tokenType int
+		value     string
+	}{
+		{"42", TOKEN_INT_LITERAL, "42"},
+		{"0", TOKEN_INT_LITERAL, "0"},
+		{"1.5", TOKEN_FLOAT_LITERAL, "1.5"},
+		{"12.25", TOKEN_FLOAT_LITERAL, "12.25"},
+		{"0.5", TOKEN_FLOAT_LITERAL, "0.5"},
+		{"3.0", TOKEN_FLOAT_LITERAL, "3.0"},
+	}
+
+	for _, test := range tests {
+		err, tokens := newLexer(test.text).tokenize()
+		if err != nil {
+			t.Errorf("%s: tokenize failed: %s", test.text, err)
+			continue
+		}
+
+		if tokens[0].tokenType != test.tokenType || tokens[0].tokenValue != test.value {
+			t.Errorf("%s: got %s %q, expected %s %q", test.text, tokenTypesString[tokens[0].tokenType], tokens[0].tokenValue, tokenTypesString[test.tokenType], test.value)
+		}
+	}
+}
+
+func TestInvalidNumbers(t *testing.T) {
+	for _, text := range []string{"1.2.3", "01", "00.5"} {
+		err, _ := newLexer(text).tokenize()
+		if err == nil {
+			t.Errorf("%s: tokenize succeeded", text)
+		}
+	}
+}
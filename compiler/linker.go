@@ -0,0 +1,324 @@
+package compiler
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+type BuildOptions struct {
+	ProgramName    string
+	BuildDirectory string
+	Entry          string
+	SourceRoots    []string
+	Compiler       string
+	Optimization   string
+	Target         string
+	DataLayout     string
+	CompilerFlags  []string
+	Objects        []string
+	LibraryPaths   []string
+	Libraries      []string
+	LinkerFlags    []string
+	Archiver       string
+	// LIBRARY_STATIC or LIBRARY_SHARED to build a library instead of an executable
+	Library        string
+	// keep the names of exported functions and write a C header for every module
+	ExportC        bool
+	Jobs           int
+	// BACKEND_LLVM, BACKEND_C or BACKEND_WASM, the code generated for the modules
+	Backend        string
+	// number of errors reported, the first ones by location, 0 reports all of them
+	ErrorLimit     int
+	// where the output of the tools and of the programs that are run goes, nothing is written
+	// when they are not set
+	Stdout         io.Writer
+	Stderr         io.Writer
+	// input of the programs that are run
+	Stdin          io.Reader
+}
+
+const (
+	LIBRARY_STATIC = "static"
+	LIBRARY_SHARED = "shared"
+)
+
+var libraryKinds = []string{LIBRARY_STATIC, LIBRARY_SHARED}
+
+// defaultOutput is the name of what is built when no output is given
+func (this *BuildOptions) defaultOutput() string {
+	switch this.Library {
+	case LIBRARY_STATIC:
+		return "./liboutput.a"
+	case LIBRARY_SHARED:
+		return "./liboutput.so"
+	}
+
+	return "./output.exe"
+}
+
+func (this *BuildOptions) stdout() io.Writer {
+	if this.Stdout == nil {
+		return io.Discard
+	}
+
+	return this.Stdout
+}
+
+func (this *BuildOptions) stderr() io.Writer {
+	if this.Stderr == nil {
+		return io.Discard
+	}
+
+	return this.Stderr
+}
+
+type Linker struct {
+	options *BuildOptions
+	graph *ModuleGraph
+	compilers []Backend
+	// objects of the modules loaded from interface files
+	interfaceObjects []string
+	cache *BuildCache
+}
+
+func newLinker(graph *ModuleGraph, options *BuildOptions) *Linker {
+	// create compilers, imported modules first
+	var compilers []Backend
+	var interfaceObjects []string
+	for _, moduleName := range graph.order {
+		if graph.isInterface(moduleName) {
+			interfaceFile := graph.fileNames[graph.modules[moduleName][0]]
+			interfaceObjects = append(interfaceObjects, interfaceObjectPath(interfaceFile))
+			continue
+		}
+
+		compiler := newBackend(graph.modules[moduleName], moduleName, options)
+		compilers = append(compilers, compiler)
+	}
+
+	return &Linker{
+		options: options,
+		graph: graph,
+		compilers: compilers,
+		interfaceObjects: interfaceObjects,
+		cache: newBuildCache(options.BuildDirectory),
+	}
+}
+
+func (this *Linker) cacheKeys() (error, map[string]string) {
+	interfaceHashes := make(map[string]string)
+	for moduleName, asts := range this.graph.modules {
+		interfaceHashes[moduleName] = moduleInterfaceHash(asts)
+	}
+
+	keys := make(map[string]string)
+	for _, compiler := range this.compilers {
+		err, sourceHash := moduleSourceHash(compiler.sources())
+		if err != nil {
+			return err, nil
+		}
+
+		// the layout of a struct of an indirect import can be used by value through a direct import,
+		// so every module the code depends on is part of the key
+		var importHashes []string
+		for _, importName := range this.graph.transitiveImports(compiler.module()) {
+			// modules outside of the build have nothing to hash
+			if importHash, ok := interfaceHashes[importName]; ok {
+				importHashes = append(importHashes, importName+" "+importHash)
+			}
+		}
+
+		keys[compiler.module()] = moduleCacheKey(sourceHash, importHashes, compiler.cacheFlags())
+	}
+
+	return nil, keys
+}
+
+func (this *Linker) hasModule(moduleName string) bool {
+	for _, compiler := range this.compilers {
+		if compiler.module() == moduleName {
+			return true
+		}
+	}
+
+	return false
+}
+
+// writeInterfaces writes the interface file of every compiled module in the given directory
+func (this *Linker) writeInterfaces(directory string) error {
+	for _, compiler := range this.compilers {
+		err, data := encodeJSON(moduleInterface(compiler.sources()))
+		if err != nil {
+			return err
+		}
+
+		err = os.WriteFile(filepath.Join(directory, compiler.module()+interfaceExtension), data, 0644)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (this *Linker) Link() error {
+	// libraries don't have an entry
+	if this.options.Library == "" && this.options.Entry != "" && !this.hasModule(this.options.Entry) {
+		return fmt.Errorf("Entry module not found: %s", this.options.Entry)
+	}
+
+	err := this.cache.prepare()
+	if err != nil {
+		return err
+	}
+
+	err, keys := this.cacheKeys()
+	if err != nil {
+		return err
+	}
+
+	var outputs []string
+	for _, compiler := range this.compilers {
+		outputs = append(outputs, this.cache.objectPath(compiler.module()))
+	}
+
+	// the compilers don't depend on each other, the output of the toolchain is buffered so it is
+	// printed in the order of the modules
+	diagnostics := make([]bytes.Buffer, len(this.compilers))
+
+	err = runJobs(this.options.Jobs, len(this.compilers), func(index int) error {
+		compiler := this.compilers[index]
+		compiler.setOutput(&diagnostics[index])
+
+		moduleName := compiler.module()
+		if this.cache.isValid(moduleName, keys[moduleName]) {
+			return nil
+		}
+
+		this.cache.invalidate(moduleName)
+
+		err := compiler.Compile(outputs[index])
+		if err != nil {
+			return err
+		}
+
+		return this.cache.store(moduleName, keys[moduleName])
+	})
+
+	for index := range diagnostics {
+		this.options.stderr().Write(diagnostics[index].Bytes())
+	}
+
+	if err != nil {
+		return err
+	}
+
+	err = this.writeInterfaces(this.cache.directory)
+	if err != nil {
+		return err
+	}
+
+	for _, object := range this.interfaceObjects {
+		_, err := os.Stat(object)
+		if err == nil {
+			outputs = append(outputs, object)
+			continue
+		}
+
+		if !this.options.providedByLibrary(object) {
+			return fmt.Errorf("Object of module interface not found: %s", object)
+		}
+	}
+
+	// wasm modules are linked by the host that loads them
+	if this.options.backend() == BACKEND_WASM {
+		return this.writeModules(filepath.Dir(this.options.ProgramName))
+	}
+
+	outputs = append(outputs, this.options.Objects...)
+
+	switch this.options.Library {
+	case LIBRARY_STATIC:
+		// ar keeps the members of an existing archive, the ones of removed modules would stay
+		err = os.Remove(this.options.ProgramName)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		err = this.runTool(this.options.archiverTool(), append([]string{"rcs", this.options.ProgramName}, outputs...))
+	case LIBRARY_SHARED:
+		arguments := append([]string{"-shared"}, outputs...)
+		arguments = append(arguments, this.options.linkFlags()...)
+		arguments = append(arguments, "-o", this.options.ProgramName)
+
+		err = this.runTool(this.options.toolchain(), arguments)
+	default:
+		arguments := append([]string{}, outputs...)
+		arguments = append(arguments, this.options.linkFlags()...)
+		arguments = append(arguments, "-o", this.options.ProgramName)
+
+		err = this.runTool(this.options.toolchain(), arguments)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// libraries are shipped with the interfaces of their modules so bir programs can import them
+	if this.options.Library != "" {
+		err = this.writeInterfaces(filepath.Dir(this.options.ProgramName))
+		if err != nil {
+			return err
+		}
+	}
+
+	if this.options.ExportC {
+		return this.writeHeaders(filepath.Dir(this.options.ProgramName))
+	}
+
+	return nil
+}
+
+// writeModules copies the code of every compiled module in the given directory
+func (this *Linker) writeModules(directory string) error {
+	for _, compiler := range this.compilers {
+		data, err := os.ReadFile(this.cache.objectPath(compiler.module()))
+		if err != nil {
+			return err
+		}
+
+		err = os.WriteFile(filepath.Join(directory, compiler.module()+compiler.extension()), data, 0644)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeHeaders writes a C header for every compiled module in the given directory
+func (this *Linker) writeHeaders(directory string) error {
+	for _, compiler := range this.compilers {
+		headerPath := filepath.Join(directory, compiler.module()+".h")
+
+		err := os.WriteFile(headerPath, []byte(moduleHeader(compiler)), 0644)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (this *Linker) runTool(tool string, arguments []string) error {
+	cmd := exec.Command(tool, arguments...)
+
+	cmd.Stdout = this.options.stdout()
+	cmd.Stderr = this.options.stderr()
+
+	return cmd.Run()
+}
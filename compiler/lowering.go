@@ -0,0 +1,79 @@
+package compiler
+
+// how the types of bir are represented by the backends, so they agree on the values passed
+// between functions and on the fields of structs
+const (
+	LOWERED_VOID    = iota
+	// 64 bit signed integer
+	LOWERED_INT     = iota
+	// 64 bit float
+	LOWERED_FLOAT   = iota
+	// byte holding 0 or 1
+	LOWERED_BOOL    = iota
+	// address of zero terminated bytes, strings are C strings
+	LOWERED_STRING  = iota
+	// address of an instance, structs are always used through pointers
+	LOWERED_STRUCT  = iota
+	// address of a value of the element type
+	LOWERED_POINTER = iota
+)
+
+func lowerType(birType *SymbolType) int {
+	if birType.kind == TYPE_POINTER {
+		return LOWERED_POINTER
+	}
+
+	switch birType.name {
+	case "int":
+		return LOWERED_INT
+	case "float":
+		return LOWERED_FLOAT
+	case "bool":
+		return LOWERED_BOOL
+	case "void":
+		return LOWERED_VOID
+	case "string":
+		return LOWERED_STRING
+	}
+
+	return LOWERED_STRUCT
+}
+
+// structFields returns the types of the fields of a struct in the order they are laid out
+func structFields(symbol *Symbol) []*SymbolType {
+	var fields []*SymbolType
+	for field := symbol.node.right; field != nil; field = field.next {
+		fields = append(fields, &field.symbol.simbolType)
+	}
+
+	return fields
+}
+
+// externDeclarations keeps the first declaration of every C function a module calls, by link
+// name. The same C function can be declared by several modules, but with one signature
+type externDeclarations map[string]*Symbol
+
+// add records the declaration of an extern function and tells if the C function was already
+// declared
+func (this externDeclarations) add(symbol *Symbol) (error, bool) {
+	declared, ok := this[symbol.linkName]
+	if !ok {
+		this[symbol.linkName] = symbol
+		return nil, false
+	}
+
+	if !sameSignature(declared.simbolType.signature, symbol.simbolType.signature) {
+		diagnostic := nodeDiagnostic(symbol.node, "Extern function %s is declared with another signature", symbol.linkName)
+		if symbol.fileName != nil {
+			diagnostic.File = *symbol.fileName
+		}
+
+		if declared.fileName != nil && declared.node != nil && declared.node.token != nil {
+			diagnostic.note("previous declaration: %s:%d:%d", *declared.fileName, declared.node.token.line, declared.node.token.column)
+		}
+
+		return diagnostic, true
+	}
+
+	return nil, true
+}
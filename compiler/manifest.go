@@ -0,0 +1,423 @@
+package compiler
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const ManifestFileName = "bir.toml"
+
+// Manifest describes how a project is built, it is read from a bir.toml file:
+//
+//	[project]
+//	entry = "main"
+//	sources = ["src"]
+//	output = "program.exe"
+//	library = "static"
+//	export-c = true
+//	build-dir = ".bir-build"
+//
+//	[toolchain]
+//	cc = "clang"
+//	target = "x86_64-pc-linux-gnu"
+//	datalayout = "e-m:e-i64:64-n8:16:32:64-S128"
+//
+//	[compiler]
+//	backend = "llvm"
+//	optimization = "2"
+//	flags = ["-g"]
+//
+//	[link]
+//	objects = ["vendor/helpers.o"]
+//	library-paths = ["vendor/lib"]
+//	libraries = ["m"]
+//	flags = ["-static"]
+type Manifest struct {
+	directory      string
+	entry          string
+	sources        []string
+	output         string
+	library        string
+	exportC        bool
+	buildDirectory string
+	compiler       string
+	target         string
+	dataLayout     string
+	backend        string
+	optimization   string
+	compilerFlags  []string
+	objects        []string
+	libraryPaths   []string
+	libraries      []string
+	linkerFlags    []string
+}
+
+type manifestParser struct {
+	fileName string
+	values   map[string]any
+	section  string
+}
+
+func (this *manifestParser) errorAt(line int, message string, arguments ...any) error {
+	return fmt.Errorf("%s:%d: %s", this.fileName, line, fmt.Sprintf(message, arguments...))
+}
+
+func (this *manifestParser) parseValue(line int, text string) (error, any) {
+	if strings.HasPrefix(text, "\"") {
+		value, err := strconv.Unquote(text)
+		if err != nil {
+			return this.errorAt(line, "Invalid string: %s", text), nil
+		}
+
+		return nil, value
+	}
+
+	if strings.HasPrefix(text, "[") {
+		if !strings.HasSuffix(text, "]") {
+			return this.errorAt(line, "Array opened but not closed"), nil
+		}
+
+		values := []string{}
+		for _, item := range splitArrayItems(text[1 : len(text)-1]) {
+			err, value := this.parseValue(line, item)
+			if err != nil {
+				return err, nil
+			}
+
+			stringValue, ok := value.(string)
+			if !ok {
+				return this.errorAt(line, "Only arrays of strings are supported"), nil
+			}
+
+			values = append(values, stringValue)
+		}
+
+		return nil, values
+	}
+
+	if text == "true" || text == "false" {
+		return nil, text == "true"
+	}
+
+	return this.errorAt(line, "Invalid value: %s", text), nil
+}
+
+// splitArrayItems splits the inside of an array on the commas that are not part of a string
+func splitArrayItems(text string) []string {
+	var items []string
+
+	inString := false
+	start := 0
+	for index := 0; index < len(text); index++ {
+		switch text[index] {
+		case '\\':
+			index++
+		case '"':
+			inString = !inString
+		case ',':
+			if !inString {
+				items = append(items, text[start:index])
+				start = index + 1
+			}
+		}
+	}
+	items = append(items, text[start:])
+
+	var trimmed []string
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			trimmed = append(trimmed, item)
+		}
+	}
+
+	return trimmed
+}
+
+func stripComment(text string) string {
+	inString := false
+	for index := 0; index < len(text); index++ {
+		switch text[index] {
+		case '\\':
+			index++
+		case '"':
+			inString = !inString
+		case '#':
+			if !inString {
+				return text[:index]
+			}
+		}
+	}
+
+	return text
+}
+
+func (this *manifestParser) parse(text string) error {
+	lines := strings.Split(text, "\n")
+	for index := 0; index < len(lines); index++ {
+		lineNumber := index + 1
+		line := strings.TrimSpace(stripComment(lines[index]))
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && !strings.Contains(line, "=") {
+			if !strings.HasSuffix(line, "]") {
+				return this.errorAt(lineNumber, "Invalid section: %s", line)
+			}
+
+			this.section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			return this.errorAt(lineNumber, "Expected key = value")
+		}
+
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		// arrays can span multiple lines
+		for strings.HasPrefix(value, "[") && !strings.HasSuffix(value, "]") && index+1 < len(lines) {
+			index++
+			value = value + " " + strings.TrimSpace(stripComment(lines[index]))
+		}
+
+		err, parsedValue := this.parseValue(lineNumber, value)
+		if err != nil {
+			return err
+		}
+
+		fullKey := key
+		if this.section != "" {
+			fullKey = this.section + "." + key
+		}
+
+		if _, ok := this.values[fullKey]; ok {
+			return this.errorAt(lineNumber, "Key already defined: %s", fullKey)
+		}
+
+		this.values[fullKey] = parsedValue
+	}
+
+	return nil
+}
+
+func (this *manifestParser) getString(key string, defaultValue string) (error, string) {
+	value, ok := this.values[key]
+	if !ok {
+		return nil, defaultValue
+	}
+
+	stringValue, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("%s: %s must be a string", this.fileName, key), ""
+	}
+
+	return nil, stringValue
+}
+
+func (this *manifestParser) getBool(key string, defaultValue bool) (error, bool) {
+	value, ok := this.values[key]
+	if !ok {
+		return nil, defaultValue
+	}
+
+	boolValue, ok := value.(bool)
+	if !ok {
+		return fmt.Errorf("%s: %s must be a boolean", this.fileName, key), false
+	}
+
+	return nil, boolValue
+}
+
+func (this *manifestParser) getStrings(key string, defaultValue []string) (error, []string) {
+	value, ok := this.values[key]
+	if !ok {
+		return nil, defaultValue
+	}
+
+	stringsValue, ok := value.([]string)
+	if !ok {
+		return fmt.Errorf("%s: %s must be an array of strings", this.fileName, key), nil
+	}
+
+	return nil, stringsValue
+}
+
+var manifestKeys = []string{
+	"project.entry",
+	"project.sources",
+	"project.output",
+	"project.library",
+	"project.export-c",
+	"project.build-dir",
+	"toolchain.cc",
+	"toolchain.target",
+	"toolchain.datalayout",
+	"compiler.backend",
+	"compiler.optimization",
+	"compiler.flags",
+	"link.objects",
+	"link.library-paths",
+	"link.libraries",
+	"link.flags",
+}
+
+func LoadManifest(fileName string) (error, *Manifest) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return err, nil
+	}
+
+	parser := &manifestParser{
+		fileName: fileName,
+		values:   make(map[string]any),
+	}
+
+	err = parser.parse(string(data))
+	if err != nil {
+		return err, nil
+	}
+
+	for key := range parser.values {
+		if findString(manifestKeys, key) < 0 {
+			return fmt.Errorf("%s: Unknown key: %s", fileName, key), nil
+		}
+	}
+
+	manifest := &Manifest{
+		directory: filepath.Dir(fileName),
+	}
+
+	err, manifest.entry = parser.getString("project.entry", "main")
+	if err != nil {
+		return err, nil
+	}
+
+	err, manifest.sources = parser.getStrings("project.sources", []string{"."})
+	if err != nil {
+		return err, nil
+	}
+
+	err, manifest.output = parser.getString("project.output", "")
+	if err != nil {
+		return err, nil
+	}
+
+	err, manifest.library = parser.getString("project.library", "")
+	if err != nil {
+		return err, nil
+	}
+
+	err, manifest.exportC = parser.getBool("project.export-c", false)
+	if err != nil {
+		return err, nil
+	}
+
+	err, manifest.buildDirectory = parser.getString("project.build-dir", DefaultBuildDirectory)
+	if err != nil {
+		return err, nil
+	}
+
+	err, manifest.compiler = parser.getString("toolchain.cc", "")
+	if err != nil {
+		return err, nil
+	}
+
+	err, manifest.target = parser.getString("toolchain.target", "")
+	if err != nil {
+		return err, nil
+	}
+
+	err, manifest.dataLayout = parser.getString("toolchain.datalayout", "")
+	if err != nil {
+		return err, nil
+	}
+
+	err, manifest.backend = parser.getString("compiler.backend", "")
+	if err != nil {
+		return err, nil
+	}
+
+	err, manifest.optimization = parser.getString("compiler.optimization", "")
+	if err != nil {
+		return err, nil
+	}
+
+	err, manifest.compilerFlags = parser.getStrings("compiler.flags", nil)
+	if err != nil {
+		return err, nil
+	}
+
+	err, manifest.objects = parser.getStrings("link.objects", nil)
+	if err != nil {
+		return err, nil
+	}
+
+	err, manifest.libraryPaths = parser.getStrings("link.library-paths", nil)
+	if err != nil {
+		return err, nil
+	}
+
+	err, manifest.libraries = parser.getStrings("link.libraries", nil)
+	if err != nil {
+		return err, nil
+	}
+
+	err, manifest.linkerFlags = parser.getStrings("link.flags", nil)
+	if err != nil {
+		return err, nil
+	}
+
+	return nil, manifest
+}
+
+// path resolves a path written in the manifest relative to the manifest directory
+func (this *Manifest) path(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+
+	return filepath.Join(this.directory, path)
+}
+
+func (this *Manifest) paths(paths []string) []string {
+	var resolved []string
+	for _, path := range paths {
+		resolved = append(resolved, this.path(path))
+	}
+
+	return resolved
+}
+
+func (this *Manifest) BuildOptions() *BuildOptions {
+	// the default output depends on what is built
+	programName := ""
+	if this.output != "" {
+		programName = this.path(this.output)
+	}
+
+	return &BuildOptions{
+		ProgramName:    programName,
+		Library:        this.library,
+		ExportC:        this.exportC,
+		BuildDirectory: this.path(this.buildDirectory),
+		Entry:          this.entry,
+		SourceRoots:    this.paths(this.sources),
+		Compiler:       this.compiler,
+		Backend:        this.backend,
+		Optimization:   this.optimization,
+		Target:         this.target,
+		DataLayout:     this.dataLayout,
+		CompilerFlags:  this.compilerFlags,
+		Objects:        this.paths(this.objects),
+		LibraryPaths:   this.paths(this.libraryPaths),
+		Libraries:      this.libraries,
+		LinkerFlags:    this.linkerFlags,
+	}
+}
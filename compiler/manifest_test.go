@@ -0,0 +1,200 @@
+package compiler
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func parseManifestText(text string) (error, map[string]any) {
+	parser := &manifestParser{
+		fileName: "bir.toml",
+		values:   make(map[string]any),
+	}
+
+	err := parser.parse(text)
+	if err != nil {
+		return err, nil
+	}
+
+	return nil, parser.values
+}
+
+func TestManifestValues(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		values map[string]any
+	}{
+		{
+			name:   "string",
+			text:   "[project]\nentry = \"app\"\n",
+			values: map[string]any{"project.entry": "app"},
+		},
+		{
+			name:   "escaped quote",
+			text:   "[project]\noutput = \"a \\\"b\\\" c\"\n",
+			values: map[string]any{"project.output": "a \"b\" c"},
+		},
+		{
+			name:   "boolean",
+			text:   "[project]\nexport-c = true\n",
+			values: map[string]any{"project.export-c": true},
+		},
+		{
+			name:   "key outside of a section",
+			text:   "entry = \"app\"\n",
+			values: map[string]any{"entry": "app"},
+		},
+		{
+			name:   "comments",
+			text:   "# project\n[project] # the project\nentry = \"app\" # entry module\n",
+			values: map[string]any{"project.entry": "app"},
+		},
+		{
+			name:   "hash inside a string",
+			text:   "[link]\nflags = [\"-Wl,#1\"]\n",
+			values: map[string]any{"link.flags": []string{"-Wl,#1"}},
+		},
+		{
+			name:   "array",
+			text:   "[link]\nlibraries = [\"m\", \"pthread\"]\n",
+			values: map[string]any{"link.libraries": []string{"m", "pthread"}},
+		},
+		{
+			name:   "empty array",
+			text:   "[link]\nlibraries = []\n",
+			values: map[string]any{"link.libraries": []string{}},
+		},
+		{
+			name:   "comma inside a string",
+			text:   "[link]\nflags = [\"-Wl,-rpath,lib\", \"-g\",]\n",
+			values: map[string]any{"link.flags": []string{"-Wl,-rpath,lib", "-g"}},
+		},
+		{
+			name:   "multiline array",
+			text:   "[project]\nsources = [\n\t\"src\", # sources\n\t\"vendor\",\n]\n",
+			values: map[string]any{"project.sources": []string{"src", "vendor"}},
+		},
+		{
+			name: "sections",
+			text: "[compiler]\nflags = [\"-g\"]\n\n[link]\nflags = [\"-static\"]\n",
+			values: map[string]any{
+				"compiler.flags": []string{"-g"},
+				"link.flags":     []string{"-static"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		err, values := parseManifestText(test.text)
+		if err != nil {
+			t.Errorf("%s: parse failed: %s", test.name, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(values, test.values) {
+			t.Errorf("%s: got %#v, expected %#v", test.name, values, test.values)
+		}
+	}
+}
+
+func TestManifestErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		error string
+	}{
+		{"unclosed string", "[project]\nentry = \"app\n", "bir.toml:2: Invalid string: \"app"},
+		{"unclosed array", "[link]\nlibraries = [\"m\"\n", "bir.toml:2: Array opened but not closed"},
+		{"array of booleans", "[link]\n\nlibraries = [true]\n", "bir.toml:3: Only arrays of strings are supported"},
+		{"bare word", "[project]\nentry = app\n", "bir.toml:2: Invalid value: app"},
+		{"number", "[project]\njobs = 4\n", "bir.toml:2: Invalid value: 4"},
+		{"unclosed section", "[project\n", "bir.toml:1: Invalid section: [project"},
+		{"missing value", "[project]\n\n\nentry\n", "bir.toml:4: Expected key = value"},
+		{"key defined twice", "[project]\nentry = \"a\"\nentry = \"b\"\n", "bir.toml:3: Key already defined: project.entry"},
+	}
+
+	for _, test := range tests {
+		err, _ := parseManifestText(test.text)
+		if err == nil {
+			t.Errorf("%s: parse succeeded", test.name)
+			continue
+		}
+
+		if err.Error() != test.error {
+			t.Errorf("%s: got error %q, expected %q", test.name, err.Error(), test.error)
+		}
+	}
+}
+
+func writeManifest(t *testing.T, text string) string {
+	t.Helper()
+
+	fileName := filepath.Join(t.TempDir(), ManifestFileName)
+	err := os.WriteFile(fileName, []byte(text), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return fileName
+}
+
+func TestLoadManifestErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		error string
+	}{
+		{"unknown key", "[project]\nname = \"app\"\n", "Unknown key: project.name"},
+		{"string expected", "[project]\nentry = true\n", "project.entry must be a string"},
+		{"boolean expected", "[project]\nexport-c = \"yes\"\n", "project.export-c must be a boolean"},
+		{"array expected", "[link]\nlibraries = \"m\"\n", "link.libraries must be an array of strings"},
+	}
+
+	for _, test := range tests {
+		err, _ := LoadManifest(writeManifest(t, test.text))
+		if err == nil {
+			t.Errorf("%s: load succeeded", test.name)
+			continue
+		}
+
+		if !strings.HasSuffix(err.Error(), test.error) {
+			t.Errorf("%s: got error %q, expected %q", test.name, err.Error(), test.error)
+		}
+	}
+}
+
+func TestManifestBuildOptions(t *testing.T) {
+	fileName := writeManifest(t, "[project]\nsources = [\"src\"]\noutput = \"out/app.exe\"\n\n[link]\nobjects = [\"/abs/helpers.o\"]\n")
+	directory := filepath.Dir(fileName)
+
+	err, manifest := LoadManifest(fileName)
+	if err != nil {
+		t.Fatalf("load failed: %s", err)
+	}
+
+	options := manifest.BuildOptions()
+
+	if options.Entry != "main" {
+		t.Errorf("entry: got %q, expected the default main", options.Entry)
+	}
+
+	if !reflect.DeepEqual(options.SourceRoots, []string{filepath.Join(directory, "src")}) {
+		t.Errorf("sources are not relative to the manifest: %v", options.SourceRoots)
+	}
+
+	if options.ProgramName != filepath.Join(directory, "out", "app.exe") {
+		t.Errorf("output is not relative to the manifest: %s", options.ProgramName)
+	}
+
+	if options.BuildDirectory != filepath.Join(directory, DefaultBuildDirectory) {
+		t.Errorf("build directory: got %s", options.BuildDirectory)
+	}
+
+	if !reflect.DeepEqual(options.Objects, []string{"/abs/helpers.o"}) {
+		t.Errorf("absolute paths are changed: %v", options.Objects)
+	}
+}
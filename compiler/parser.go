@@ -1,6 +1,9 @@
-package main
+package compiler
 
-import "fmt"
+import (
+	"fmt"
+	"io"
+)
 
 const (
 	NODE_PROGRAM              = iota
@@ -40,6 +43,12 @@ const (
 	NODE_INDEX                = iota
 	NODE_WITH                 = iota
 	NODE_LINK                 = iota
+	NODE_EXTERN               = iota
+	NODE_POINTER_TYPE         = iota
+	NODE_ADDRESS              = iota
+	NODE_DEREFERENCE          = iota
+	NODE_CAST                 = iota
+	NODE_UNSAFE               = iota
 )
 
 var nodeStrings = []string{
@@ -80,6 +89,12 @@ var nodeStrings = []string{
 	"NODE_INDEX",
 	"NODE_WITH",
 	"NODE_LINK",
+	"NODE_EXTERN",
+	"NODE_POINTER_TYPE",
+	"NODE_ADDRESS",
+	"NODE_DEREFERENCE",
+	"NODE_CAST",
+	"NODE_UNSAFE",
 }
 
 type Node struct {
@@ -90,6 +105,10 @@ type Node struct {
 	next     *Node
 	symbolTable *SymbolTable
 	symbol *Symbol
+	// type of the expression, set by the checker
+	symbolType *SymbolType
+	// declared with export, visible to other modules
+	exported bool
 }
 
 func (this *Node) ToString() string {
@@ -98,6 +117,10 @@ func (this *Node) ToString() string {
 		tokenString = this.token.toString()
 	}
 
+	if this.exported {
+		return fmt.Sprintf("node: %s, token: %s, exported", nodeStrings[this.nodeType], tokenString)
+	}
+
 	return fmt.Sprintf("node: %s, token: %s", nodeStrings[this.nodeType], tokenString)
 }
 
@@ -128,18 +151,18 @@ const (
 	nextNodeSymbol      = " ─> "
 )
 
-func (this *Node) Dump(indent int, bars *[]int, nodeSymbol string) {
+func (this *Node) Dump(writer io.Writer, indent int, bars *[]int, nodeSymbol string) {
 	for i := 0; i < indent-4; i++ {
 		if contains(*bars, i) {
-			fmt.Print(barSymbol)
+			fmt.Fprint(writer, barSymbol)
 		} else {
-			fmt.Print(emptySymbol)
+			fmt.Fprint(writer, emptySymbol)
 		}
 	}
 
-	fmt.Print(nodeSymbol)
+	fmt.Fprint(writer, nodeSymbol)
 
-	fmt.Println(this.ToString())
+	fmt.Fprintln(writer, this.ToString())
 
 	clonedBars := clone(*bars)
 
@@ -150,15 +173,15 @@ func (this *Node) Dump(indent int, bars *[]int, nodeSymbol string) {
 	}
 
 	if this.left != nil {
-		this.left.Dump(indent+4, &clonedBars, leftNodeCharacter)
+		this.left.Dump(writer, indent+4, &clonedBars, leftNodeCharacter)
 	}
 
 	if this.right != nil {
-		this.right.Dump(indent+4, bars, normalNodeSymbol)
+		this.right.Dump(writer, indent+4, bars, normalNodeSymbol)
 	}
 
 	if this.next != nil {
-		this.next.Dump(indent, bars, nextNodeSymbol)
+		this.next.Dump(writer, indent, bars, nextNodeSymbol)
 	}
 }
 
@@ -168,6 +191,12 @@ type Parser struct {
 
 	asAllowed bool
 	startExpression *Node
+
+	// error of the lexer skipped right before the current token, the errors of the parser at this
+	// token follow from it, so it is returned in their place
+	lexerError error
+	// errors of the statements and declarations skipped so far
+	diagnostics Diagnostics
 }
 
 func newParser(lexer *Lexer) *Parser {
@@ -179,14 +208,78 @@ func newParser(lexer *Lexer) *Parser {
 }
 
 func (this *Parser) invalidTokenError(expectedTokenType int) error {
-	err := fmt.Errorf("Invalid token: %s, expected: %s, line: %d, column: %d", this.currentToken.toString(), tokenTypesString[expectedTokenType], this.currentToken.line, this.currentToken.column)
+	if this.lexerError != nil {
+		return this.lexerError
+	}
 
-	panic(err)
+	return tokenDiagnostic(this.currentToken, "Invalid token: %s", this.currentToken.toString()).note("expected: %s", tokenTypesString[expectedTokenType])
 }
 
 func (this *Parser) unexpectedTokenError() error {
-	err := fmt.Errorf("Unexpected token: %s, line: %d, column: %d", this.currentToken.toString(), this.currentToken.line, this.currentToken.column)
-	panic(err)
+	if this.lexerError != nil {
+		return this.lexerError
+	}
+
+	return tokenDiagnostic(this.currentToken, "Unexpected token: %s", this.currentToken.toString())
+}
+
+// report records an error, the error of a statement is also returned by the blocks it is in that
+// can't go on, so it is recorded once
+func (this *Parser) report(err error) {
+	for _, diagnostic := range toDiagnostics(err) {
+		found := false
+		for _, existing := range this.diagnostics {
+			if existing == diagnostic {
+				found = true
+			}
+		}
+
+		if !found {
+			this.diagnostics = append(this.diagnostics, diagnostic)
+		}
+	}
+}
+
+// synchronize skips the tokens of a statement or of a declaration that has an error, up to the
+// closing bracket of the block it is in or up to the next declaration. The blocks opened by the
+// skipped tokens are skipped with them.
+func (this *Parser) synchronize() {
+	depth := 0
+	for {
+		switch this.currentToken.tokenType {
+		case TOKEN_EOF, TOKEN_FUNCTION, TOKEN_STRUCT, TOKEN_IMPLEMENT:
+			return
+		case TOKEN_OPEN_BRACKET:
+			depth++
+		case TOKEN_CLOSED_BRACKET:
+			if depth == 0 {
+				return
+			}
+
+			depth--
+		}
+
+		this.advance()
+	}
+}
+
+// recoverInBlock reports the error of a member of a block and skips it, it tells if the block goes
+// on, which it does at its closing bracket or at one of the given tokens
+func (this *Parser) recoverInBlock(err error, tokenTypes ...int) bool {
+	this.report(err)
+	this.synchronize()
+
+	if this.currentToken.tokenType == TOKEN_CLOSED_BRACKET {
+		return true
+	}
+
+	for _, tokenType := range tokenTypes {
+		if this.currentToken.tokenType == tokenType {
+			return true
+		}
+	}
+
+	return false
 }
 
 func (this *Parser) expectToken(expectedTokenType int) error {
@@ -208,14 +301,20 @@ func (this *Parser) eat(expectedTokenType int) error {
 	return nil
 }
 
-func (this *Parser) advance() error {
-	err, token := this.lexer.next()
-	if err != nil {
-		return err
-	}
+func (this *Parser) advance() {
+	this.lexerError = nil
+	for {
+		err, token := this.lexer.next()
+		if err == nil {
+			this.currentToken = token
+			return
+		}
 
-	this.currentToken = token
-	return nil
+		// the invalid characters are reported and skipped by the lexer, the parse goes on with
+		// the next token
+		this.report(err)
+		this.lexerError = err
+	}
 }
 
 func (this *Parser) parseLiteral() (error, *Node) {
@@ -254,7 +353,8 @@ func (this *Parser) parsePrimary() (error, *Node) {
 	if this.currentToken.tokenType == TOKEN_OPEN_PARANTHESIS {
 		this.advance()
 
-		err, primaryNode := this.parseExpression()
+		// only the as at the top of a with names its resource
+		err, primaryNode := this.parseExpressionWithAs(false)
 		if err != nil {
 			return err, nil
 		}
@@ -287,7 +387,7 @@ func (this *Parser) parseArguments() (error, *Node) {
 
 	var argumentsNode *Node = nil
 	for currentNode := (*Node)(nil); this.currentToken.tokenType != TOKEN_CLOSED_PARANTHESIS; {
-		err, node := this.parseExpression()
+		err, node := this.parseExpressionWithAs(false)
 		if err != nil {
 			return err, nil
 		}
@@ -357,6 +457,18 @@ func (this *Parser) parsePostfix() (error, *Node) {
 		} else if this.currentToken.tokenType == TOKEN_DOT {
 			this.advance()
 
+			// dereference of a raw pointer, written p.* so it can't be taken for a multiplication
+			if this.currentToken.tokenType == TOKEN_MULTIPLY {
+				left = &Node{
+					nodeType: NODE_DEREFERENCE,
+					token:    this.currentToken,
+					left:     left,
+				}
+
+				this.advance()
+				continue
+			}
+
 			err := this.expectToken(TOKEN_IDENTIFIER)
 			if err != nil {
 				return err, nil
@@ -372,7 +484,7 @@ func (this *Parser) parsePostfix() (error, *Node) {
 		} else if this.currentToken.tokenType == TOKEN_OPEN_SQUARE {
 			this.advance()
 
-			err, expression := this.parseExpression()
+			err, expression := this.parseExpressionWithAs(false)
 			if err != nil {
 				return err, nil
 			}
@@ -410,19 +522,62 @@ func (this *Parser) parseUnary() (error, *Node) {
 		}
 	}
 
+	// address of a raw pointer
+	if this.currentToken.tokenType == TOKEN_AMPERSAND {
+		currentToken := this.currentToken
+		this.advance()
+
+		err, expression := this.parseUnary()
+		if err != nil {
+			return err, nil
+		}
+
+		return nil, &Node{
+			nodeType: NODE_ADDRESS,
+			token:    currentToken,
+			left:     expression,
+		}
+	}
+
 	return this.parsePostfix()
 }
 
-func (this *Parser) parseMultiplicative() (error, *Node) {
+func (this *Parser) parseCast() (error, *Node) {
 	err, left := this.parseUnary()
 	if err != nil {
 		return err, nil
 	}
 
+	// inside with, as names the resource instead
+	for currentToken := this.currentToken; !this.asAllowed && currentToken.tokenType == TOKEN_AS; currentToken = this.currentToken {
+		this.advance()
+
+		err, typeNode := this.parseType()
+		if err != nil {
+			return err, nil
+		}
+
+		left = &Node{
+			nodeType: NODE_CAST,
+			token:    currentToken,
+			left:     left,
+			right:    typeNode,
+		}
+	}
+
+	return nil, left
+}
+
+func (this *Parser) parseMultiplicative() (error, *Node) {
+	err, left := this.parseCast()
+	if err != nil {
+		return err, nil
+	}
+
 	for currentToken := this.currentToken; currentToken.tokenType == TOKEN_MULTIPLY || currentToken.tokenType == TOKEN_DIVIDE; currentToken = this.currentToken {
 		this.advance()
 
-		err, right := this.parseUnary()
+		err, right := this.parseCast()
 		if err != nil {
 			return err, nil
 		}
@@ -571,6 +726,16 @@ func (this *Parser) parseExpression() (error, *Node) {
 	return this.parseOr()
 }
 
+// parseExpressionWithAs parses an expression where as names a resource, as in with, or where it
+// casts. The flag is restored on every path so an error doesn't leave it to the rest of the file
+func (this *Parser) parseExpressionWithAs(asAllowed bool) (error, *Node) {
+	previous := this.asAllowed
+	this.asAllowed = asAllowed
+	defer func() { this.asAllowed = previous }()
+
+	return this.parseExpression()
+}
+
 func (this *Parser) parseExpressionStatement() (error, *Node) {
 	err, expression := this.parseExpression()
 	if err != nil {
@@ -648,13 +813,24 @@ func (this *Parser) parseType() (error, *Node) {
 		node = &Node{nodeType: NODE_FLOAT_TYPE}
 	} else if this.currentToken.tokenType == TOKEN_STRING {
 		node = &Node{nodeType: NODE_STRING_TYPE}
+	} else if this.currentToken.tokenType == TOKEN_PTR {
+		// ptr<T>, the type pointed to is the template
+		node = &Node{nodeType: NODE_POINTER_TYPE, token: this.currentToken}
 	} else if this.currentToken.tokenType == TOKEN_IDENTIFIER {
-		node = &Node{nodeType: NODE_CUSTOM_TYPE, token: this.currentToken}
+		// types of other modules are written qualified, like module.Type
+		err, pathNode := this.parsePath()
+		if err != nil {
+			return err, nil
+		}
+
+		node = &Node{nodeType: NODE_CUSTOM_TYPE, token: pathNode.token}
 	} else {
 		return this.unexpectedTokenError(), nil
 	}
 
-	this.advance()
+	if node.nodeType != NODE_CUSTOM_TYPE {
+		this.advance()
+	}
 
 	if this.currentToken.tokenType == TOKEN_LESS {
 		err, templateNode := this.parseTemplate()
@@ -726,7 +902,7 @@ func (this *Parser) parseVariableDeclaration() (error, *Node) {
 	}
 
 	if variableNode.left == nil && expressionNode == nil {
-		return fmt.Errorf("Variable needs to be either typed or initialized"), nil
+		return nodeDiagnostic(variableNode, "Variable needs to be either typed or initialized"), nil
 	}
 
 	variableNode.right = expressionNode
@@ -766,12 +942,10 @@ func (this *Parser) parseIf() (error, *Node) {
 		return err, nil
 	}
 
-	this.asAllowed = true
 	err, expressionNode := this.parseExpression()
 	if err != nil {
 		return err, nil
 	}
-	this.asAllowed = false
 
 	err, statementsNode := this.parseStatementsBlock()
 	if err != nil {
@@ -817,12 +991,10 @@ func (this *Parser) parseWhile() (error, *Node) {
 		return err, nil
 	}
 
-	this.asAllowed = true
 	err, expressionNode := this.parseExpression()
 	if err != nil {
 		return err, nil
 	}
-	this.asAllowed = false
 
 	err, statementsNode := this.parseStatementsBlock()
 	if err != nil {
@@ -896,15 +1068,16 @@ func (this *Parser) parseFor() (error, *Node) {
 }
 
 func (this *Parser) parseReturn() (error, *Node) {
+	returnNode := &Node{
+		nodeType: NODE_RETURN,
+		token:    this.currentToken,
+	}
+
 	err := this.eat(TOKEN_RETURN)
 	if err != nil {
 		return err, nil
 	}
 
-	returnNode := &Node{
-		nodeType: NODE_RETURN,
-	}
-
 	if this.currentToken.tokenType == TOKEN_CLOSED_BRACKET {
 		return nil, returnNode
 	}
@@ -925,12 +1098,10 @@ func (this *Parser) parseWith() (error, *Node) {
 		return err, nil
 	}
 
-	this.asAllowed = true
-	err, expression := this.parseExpression()
+	err, expression := this.parseExpressionWithAs(true)
 	if err != nil {
 		return err, nil
 	}
-	this.asAllowed = false
 
 	var statementsNode *Node = nil
 	if this.currentToken.tokenType == TOKEN_OPEN_BRACKET {
@@ -950,6 +1121,31 @@ func (this *Parser) parseWith() (error, *Node) {
 	}
 }
 
+func (this *Parser) parseUnsafe() (error, *Node) {
+	unsafeToken := this.currentToken
+
+	err := this.eat(TOKEN_UNSAFE)
+	if err != nil {
+		return err, nil
+	}
+
+	err = this.expectToken(TOKEN_OPEN_BRACKET)
+	if err != nil {
+		return err, nil
+	}
+
+	err, statementsNode := this.parseStatementsBlock()
+	if err != nil {
+		return err, nil
+	}
+
+	return nil, &Node{
+		nodeType: NODE_UNSAFE,
+		token:    unsafeToken,
+		left:     statementsNode,
+	}
+}
+
 func (this *Parser) parseStatement() (error, *Node) {
 	if this.currentToken.tokenType == TOKEN_CONST {
 		return this.parseConstant()
@@ -979,6 +1175,10 @@ func (this *Parser) parseStatement() (error, *Node) {
 		return this.parseReturn()
 	}
 
+	if this.currentToken.tokenType == TOKEN_UNSAFE {
+		return this.parseUnsafe()
+	}
+
 	return this.parseExpressionStatement()
 }
 
@@ -992,7 +1192,11 @@ func (this *Parser) parseStatementsBlock() (error, *Node) {
 	for currentNode := (*Node)(nil); this.currentToken.tokenType != TOKEN_CLOSED_BRACKET; {
 		err, node := this.parseStatement()
 		if err != nil {
-			return err, nil
+			if !this.recoverInBlock(err) {
+				return err, nil
+			}
+
+			continue
 		}
 
 		if currentNode == nil {
@@ -1018,7 +1222,11 @@ func (this *Parser) parseStructBlock() (error, *Node) {
 	for currentNode := (*Node)(nil); this.currentToken.tokenType != TOKEN_CLOSED_BRACKET; {
 		err, node := this.parseIdentifier(true)
 		if err != nil {
-			return err, nil
+			if !this.recoverInBlock(err) {
+				return err, nil
+			}
+
+			continue
 		}
 
 		if currentNode == nil {
@@ -1164,7 +1372,11 @@ func (this *Parser) parseInterfaceBlock() (error, *Node) {
 	for currentNode := (*Node)(nil); this.currentToken.tokenType != TOKEN_CLOSED_BRACKET; {
 		err, node := this.parseFunctionDeclaration(false)
 		if err != nil {
-			return err, nil
+			if !this.recoverInBlock(err, TOKEN_FUNCTION) {
+				return err, nil
+			}
+
+			continue
 		}
 
 		if currentNode == nil {
@@ -1221,7 +1433,11 @@ func (this *Parser) parseImplementBlock() (error, *Node) {
 
 		err, node := this.parseFunction(isConstructor)
 		if err != nil {
-			return err, nil
+			if !this.recoverInBlock(err, TOKEN_FUNCTION) {
+				return err, nil
+			}
+
+			continue
 		}
 
 		if currentNode == nil {
@@ -1311,6 +1527,40 @@ func (this *Parser) parseImplement() (error, *Node) {
 	return nil, implementNode
 }
 
+// parseExtern parses the declaration of a C function, the token of the node is the name it is
+// linked with when it differs from the name of the function:
+//
+//	extern "labs" function abs(x: int): int
+func (this *Parser) parseExtern() (error, *Node) {
+	err := this.eat(TOKEN_EXTERN)
+	if err != nil {
+		return err, nil
+	}
+
+	externNode := &Node{
+		nodeType: NODE_EXTERN,
+	}
+
+	if this.currentToken.tokenType == TOKEN_STRING_LITERAL {
+		externNode.token = this.currentToken
+		this.advance()
+	}
+
+	err = this.expectToken(TOKEN_FUNCTION)
+	if err != nil {
+		return err, nil
+	}
+
+	err, declarationNode := this.parseFunctionDeclaration(false)
+	if err != nil {
+		return err, nil
+	}
+
+	externNode.left = declarationNode
+
+	return nil, externNode
+}
+
 func (this *Parser) parseExportDeclaration() (error, *Node) {
 	if this.currentToken.tokenType == TOKEN_STRUCT {
 		return this.parseStruct()
@@ -1332,6 +1582,10 @@ func (this *Parser) parseExportDeclaration() (error, *Node) {
 		return this.parseConstant()
 	}
 
+	if this.currentToken.tokenType == TOKEN_EXTERN {
+		return this.parseExtern()
+	}
+
 	return this.unexpectedTokenError(), nil
 }
 
@@ -1340,7 +1594,11 @@ func (this *Parser) parseExportBlock() (error, *Node) {
 	for currentNode := (*Node)(nil); this.currentToken.tokenType != TOKEN_CLOSED_BRACKET; {
 		err, node := this.parseExportDeclaration()
 		if err != nil {
-			return err, nil
+			if !this.recoverInBlock(err, TOKEN_FUNCTION, TOKEN_STRUCT, TOKEN_IMPLEMENT) {
+				return err, nil
+			}
+
+			continue
 		}
 
 		if currentNode == nil {
@@ -1356,17 +1614,42 @@ func (this *Parser) parseExportBlock() (error, *Node) {
 	return nil, declarationsNode
 }
 
+// markExported marks the declarations and their members, the fields of a struct and the
+// functions of an implement are exported with them
+func markExported(node *Node) {
+	for ; node != nil; node = node.next {
+		node.exported = true
+
+		if node.nodeType == NODE_STRUCT || node.nodeType == NODE_INTERFACE || node.nodeType == NODE_IMPLEMENT {
+			for member := node.right; member != nil; member = member.next {
+				member.exported = true
+			}
+		}
+	}
+}
+
 func (this *Parser) parseExport() (error, *Node) {
 	err := this.eat(TOKEN_EXPORT)
 	if err != nil {
 		return err, nil
 	}
 
+	var node *Node
 	if this.currentToken.tokenType == TOKEN_OPEN_BRACKET {
-		return this.parseExportBlock()
+		this.advance()
+
+		err, node = this.parseExportBlock()
+	} else {
+		err, node = this.parseExportDeclaration()
+	}
+
+	if err != nil {
+		return err, nil
 	}
 
-	return this.parseExportDeclaration()
+	markExported(node)
+
+	return nil, node
 }
 
 func (this *Parser) parseRootStatement() (error, *Node) {
@@ -1394,6 +1677,10 @@ func (this *Parser) parseRootStatement() (error, *Node) {
 		return this.parseExport()
 	}
 
+	if this.currentToken.tokenType == TOKEN_EXTERN {
+		return this.parseExtern()
+	}
+
 	return this.unexpectedTokenError(), nil
 }
 
@@ -1403,13 +1690,29 @@ func (this *Parser) parsePath() (error, *Node) {
 		return err, nil
 	}
 
+	// the components of a dotted path are joined in a single token
+	pathToken := *this.currentToken
+
 	pathNode := &Node{
 		nodeType: NODE_PATH,
-		token:    this.currentToken,
+		token:    &pathToken,
 	}
 
 	this.advance()
 
+	for this.currentToken.tokenType == TOKEN_DOT {
+		this.advance()
+
+		err := this.expectToken(TOKEN_IDENTIFIER)
+		if err != nil {
+			return err, nil
+		}
+
+		pathToken.tokenValue = pathToken.tokenValue + "." + this.currentToken.tokenValue
+
+		this.advance()
+	}
+
 	return nil, pathNode
 }
 
@@ -1484,27 +1787,42 @@ func (this *Parser) parseImports() (error, *Node) {
 	return nil, importsNode
 }
 
+// Parse parses a file, the declarations that have errors are skipped. The errors are returned
+// with the declarations that parsed, unless the module and the imports of the file are not known.
 func (this *Parser) Parse() (error, *Node) {
-	err := this.advance()
-	if err != nil {
-		return err, nil
-	}
+	this.advance()
 
 	err, moduleNode := this.parseModule()
 	if err != nil {
-		return err, nil
+		this.report(err)
+		return this.diagnostics, nil
 	}
 
 	err, importsNode := this.parseImports()
 	if err != nil {
-		return err, nil
+		this.report(err)
+		return this.diagnostics, nil
 	}
 
 	var statementsNode *Node = nil
 	for currentNode := (*Node)(nil); this.currentToken.tokenType != TOKEN_EOF; {
 		err, node := this.parseRootStatement()
 		if err != nil {
-			return err, nil
+			// the parse goes on at the next declaration, a closing bracket left by the
+			// declaration is skipped
+			this.report(err)
+			this.synchronize()
+
+			if this.currentToken.tokenType == TOKEN_CLOSED_BRACKET {
+				this.advance()
+			}
+
+			continue
+		}
+
+		// an empty export block has no declaration
+		if node == nil {
+			continue
 		}
 
 		if currentNode == nil {
@@ -1513,7 +1831,11 @@ func (this *Parser) Parse() (error, *Node) {
 			currentNode.next = node
 		}
 
+		// export blocks return several declarations
 		currentNode = node
+		for currentNode.next != nil {
+			currentNode = currentNode.next
+		}
 	}
 
 	programMetadataNode := &Node{
@@ -1528,5 +1850,9 @@ func (this *Parser) Parse() (error, *Node) {
 		right:    statementsNode,
 	}
 
+	if len(this.diagnostics) > 0 {
+		return this.diagnostics, root
+	}
+
 	return nil, root
 }
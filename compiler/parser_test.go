@@ -0,0 +1,134 @@
+package compiler
+
+import "testing"
+
+func TestEmptyExportBlock(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		functions []string
+	}{
+		{"only declaration", "module a\n\nexport {\n}\n", nil},
+		{"first declaration", "module a\n\nexport {\n}\n\nfunction f() {\n}\n", []string{"f"}},
+		{"between declarations", "module a\n\nfunction f() {\n}\n\nexport {}\n\nfunction g() {\n}\n", []string{"f", "g"}},
+	}
+
+	for _, test := range tests {
+		err, root := newParser(newLexer(test.text)).Parse()
+		if err != nil {
+			t.Errorf("%s: parse failed: %s", test.name, err)
+			continue
+		}
+
+		var functions []string
+		for node := root.right; node != nil; node = node.next {
+			functions = append(functions, node.left.token.tokenValue)
+		}
+
+		if len(functions) != len(test.functions) {
+			t.Errorf("%s: got functions %v, expected %v", test.name, functions, test.functions)
+			continue
+		}
+
+		for index := range functions {
+			if functions[index] != test.functions[index] {
+				t.Errorf("%s: got functions %v, expected %v", test.name, functions, test.functions)
+				break
+			}
+		}
+	}
+}
+
+func TestLexerErrors(t *testing.T) {
+	text := "module a\n\nfunction f(): int {\n\tvar a = 1.2.3\n\tvar b = $x\n\treturn a\n}\n\nfunction g(): int {\n\treturn 1 +\n}\n\nfunction h() {\n\tvar c = 01\n}\n"
+
+	err, _ := newParser(newLexer(text)).Parse()
+	if err == nil {
+		t.Fatalf("parse succeeded")
+	}
+
+	// every invalid word is reported, and the parse goes on after them
+	expected := []string{
+		"4:10: Invalid number",
+		"5:10: Invalid token",
+		"11:1: Unexpected token: { tokenType: TOKEN_CLOSED_BRACKET, tokenValue: }",
+		"14:10: Can't have multiple zeros at start of a number",
+	}
+
+	diagnostics := toDiagnostics(err)
+	if len(diagnostics) != len(expected) {
+		t.Fatalf("got %d diagnostics, expected %d:\n%s", len(diagnostics), len(expected), err)
+	}
+
+	for index, diagnostic := range diagnostics {
+		if diagnostic.Error() != expected[index] {
+			t.Errorf("got %s, expected %s", diagnostic.Error(), expected[index])
+		}
+	}
+}
+
+func TestCastAfterConditionError(t *testing.T) {
+	text := "module a\n\nfunction f(x: int) {\n\tif x > {\n\t}\n}\n\nfunction g(x: int) {\n\twith x > {\n\t}\n}\n\nfunction h(x: int): float {\n\treturn x as float\n}\n"
+
+	err, _ := newParser(newLexer(text)).Parse()
+	if err == nil {
+		t.Fatalf("parse succeeded")
+	}
+
+	// only the conditions are reported, the casts that follow them parse
+	expected := []string{
+		"4:9: Unexpected token: { tokenType: TOKEN_OPEN_BRACKET, tokenValue: }",
+		"9:11: Unexpected token: { tokenType: TOKEN_OPEN_BRACKET, tokenValue: }",
+	}
+
+	diagnostics := toDiagnostics(err)
+	if len(diagnostics) != len(expected) {
+		t.Fatalf("got %d diagnostics, expected %d:\n%s", len(diagnostics), len(expected), err)
+	}
+
+	for index, diagnostic := range diagnostics {
+		if diagnostic.Error() != expected[index] {
+			t.Errorf("got %s, expected %s", diagnostic.Error(), expected[index])
+		}
+	}
+}
+
+// countNodes counts the nodes of a type in a tree
+func countNodes(node *Node, nodeType int) int {
+	count := 0
+	for ; node != nil; node = node.next {
+		if node.nodeType == nodeType {
+			count++
+		}
+
+		count += countNodes(node.left, nodeType) + countNodes(node.right, nodeType)
+	}
+
+	return count
+}
+
+func TestCastsInConditions(t *testing.T) {
+	text := "module a\n\nfunction f(y: int) {\n\tif (y as float) > 1.0 {\n\t}\n\n\twhile g(y as float) > y as float {\n\t}\n\n\twith open((y as float)) as file {\n\t}\n}\n"
+
+	err, root := newParser(newLexer(text)).Parse()
+	if err != nil {
+		t.Fatalf("parse failed: %s", err)
+	}
+
+	casts := countNodes(root, NODE_CAST)
+	if casts != 4 {
+		t.Errorf("got %d casts, expected 4", casts)
+	}
+
+	// the as at the top of with names the resource
+	var with *Node
+	for node := root.right.right; node != nil; node = node.next {
+		if node.nodeType == NODE_WITH {
+			with = node
+		}
+	}
+
+	if with == nil || with.left.right.token.tokenValue != "file" {
+		t.Errorf("with doesn't name its resource file")
+	}
+}
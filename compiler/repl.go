@@ -0,0 +1,297 @@
+package compiler
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const replFileName = "<repl>"
+
+// Repl reads statements and declarations and executes them one input at a time, what is declared
+// is kept for the next inputs. The value and the type of expressions are printed.
+type Repl struct {
+	checker     *Checker
+	interpreter *Interpreter
+	// declarations of every input, the root scope of the checker
+	symbolTable SymbolTable
+	output      io.Writer
+}
+
+func newRepl(output io.Writer) *Repl {
+	ast := &Node{
+		nodeType: NODE_PROGRAM,
+		left: &Node{
+			nodeType: NODE_LINK,
+			left: &Node{
+				nodeType: NODE_MODULE,
+				left: &Node{
+					nodeType: NODE_PATH,
+					token:    &Token{tokenType: TOKEN_IDENTIFIER, tokenValue: "repl"},
+				},
+			},
+		},
+	}
+
+	checker := newChecker([]*Node{ast}, []string{replFileName}, make(map[string][]*Node))
+	checker.fileName = &checker.fileNames[0]
+	checker.imports = make(map[string]string)
+
+	repl := &Repl{
+		checker:     checker,
+		interpreter: newInterpreter(nil, output),
+		symbolTable: make(SymbolTable),
+		output:      output,
+	}
+
+	ast.symbolTable = &repl.symbolTable
+	checker.symbolTables.push(&repl.symbolTable)
+
+	return repl
+}
+
+func isRootDeclaration(tokenType int) bool {
+	switch tokenType {
+	case TOKEN_STRUCT, TOKEN_INTERFACE, TOKEN_IMPLEMENT, TOKEN_FUNCTION, TOKEN_EXPORT, TOKEN_EXTERN:
+		return true
+	}
+
+	return false
+}
+
+// parseInput parses the declarations and statements of one input, in the order they are written
+func (this *Parser) parseInput() (error, []*Node) {
+	this.advance()
+
+	var nodes []*Node
+	for this.currentToken.tokenType != TOKEN_EOF {
+		var err error
+		var node *Node
+		if isRootDeclaration(this.currentToken.tokenType) {
+			err, node = this.parseRootStatement()
+		} else {
+			err, node = this.parseStatement()
+		}
+
+		if err != nil {
+			this.report(err)
+			return this.diagnostics, nil
+		}
+
+		if node != nil {
+			nodes = append(nodes, node)
+		}
+	}
+
+	if len(this.diagnostics) > 0 {
+		return this.diagnostics, nil
+	}
+
+	return nil, nodes
+}
+
+func (this *Repl) checkDeclaration(node *Node) error {
+	err := this.checker.walkRootTypes(node)
+	if err != nil {
+		return err
+	}
+
+	err = this.checker.walkRootDeclarations(node)
+	if err != nil {
+		return err
+	}
+
+	err = this.checker.walk(node)
+	if err != nil {
+		return err
+	}
+
+	return this.checker.reportedErrors()
+}
+
+// formatValue writes a value of the interpreter like it is written in the sources
+func formatValue(value any) string {
+	switch value := value.(type) {
+	case int64:
+		return strconv.FormatInt(value, 10)
+	case float64:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	case bool:
+		return strconv.FormatBool(value)
+	case string:
+		return strconv.Quote(value)
+	case *Instance:
+		if value == nil {
+			return "uninitialized"
+		}
+
+		var fields []string
+		for field := value.symbol.node.right; field != nil; field = field.next {
+			fields = append(fields, field.token.tokenValue+": "+formatValue(value.fields[field.token.tokenValue]))
+		}
+
+		return value.symbol.name + " { " + strings.Join(fields, ", ") + " }"
+	}
+
+	return "uninitialized"
+}
+
+func (this *Repl) evaluateNode(node *Node) error {
+	// export blocks chain their declarations, they are checked together
+	if node.nodeType == NODE_STRUCT || node.nodeType == NODE_INTERFACE || node.nodeType == NODE_IMPLEMENT ||
+		node.nodeType == NODE_FUNCTION || node.nodeType == NODE_EXTERN {
+		err := this.checkDeclaration(node)
+		if err != nil {
+			return err
+		}
+
+		this.interpreter.addFunctions(node)
+
+		return nil
+	}
+
+	err := this.checker.walkStatements(node)
+	if err != nil {
+		return err
+	}
+
+	err = this.checker.reportedErrors()
+	if err != nil {
+		return err
+	}
+
+	switch node.nodeType {
+	case NODE_IF, NODE_WHILE, NODE_UNSAFE, NODE_ASSIGNMENT, NODE_RETURN, NODE_VARIABLE_DECLARATION:
+		err, _ := this.interpreter.execute(node)
+		return err
+	}
+
+	err, value := this.interpreter.evaluate(node)
+	if err != nil {
+		return err
+	}
+
+	if node.symbolType != nil && node.symbolType.name != "void" {
+		fmt.Fprintf(this.output, "%s: %s\n", formatValue(value), node.symbolType.name)
+	}
+
+	return nil
+}
+
+// Evaluate runs one input, the declarations of an input that fails are forgotten
+func (this *Repl) Evaluate(text string) (err error) {
+	var declared []string
+	for name := range this.symbolTable {
+		declared = append(declared, name)
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("Internal error: %v", recovered)
+		}
+
+		if err == nil {
+			return
+		}
+
+		// the errors of the input are reported at their place in it
+		if _, ok := asDiagnostics(err); ok {
+			err = inFile(err, replFileName, text)
+		}
+
+		for name := range this.symbolTable {
+			if findString(declared, name) < 0 {
+				delete(this.symbolTable, name)
+			}
+		}
+
+		// the checker and the interpreter stop where the error is, leave the scopes they were in
+		for this.checker.symbolTables.len() > 1 {
+			this.checker.symbolTables.pop()
+		}
+
+		for this.checker.functionStack.len() > 0 {
+			this.checker.functionStack.pop()
+		}
+
+		this.checker.currentStruct = nil
+		this.checker.unsafeDepth = 0
+		this.checker.diagnostics = nil
+
+		for this.interpreter.frames.len() > 0 {
+			this.interpreter.frames.pop()
+		}
+	}()
+
+	err, nodes := newParser(newLexer(text)).parseInput()
+	if err != nil {
+		return err
+	}
+
+	for _, node := range nodes {
+		err := this.evaluateNode(node)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// openBrackets tells how many brackets the text leaves open, the input goes on until they are closed
+func openBrackets(text string) int {
+	count := 0
+	inString := false
+	for index := 0; index < len(text); index++ {
+		switch text[index] {
+		case '"':
+			inString = !inString
+		case '{':
+			if !inString {
+				count++
+			}
+		case '}':
+			if !inString {
+				count--
+			}
+		}
+	}
+
+	return count
+}
+
+// RunRepl reads inputs until the end of input or :quit, errors are printed and the session goes on
+func RunRepl(input io.Reader, output io.Writer) error {
+	repl := newRepl(output)
+	scanner := bufio.NewScanner(input)
+
+	var text strings.Builder
+	fmt.Fprint(output, "> ")
+	for scanner.Scan() {
+		line := scanner.Text()
+		if text.Len() == 0 && strings.TrimSpace(line) == ":quit" {
+			return nil
+		}
+
+		text.WriteString(line + "\n")
+
+		if openBrackets(text.String()) > 0 {
+			fmt.Fprint(output, "... ")
+			continue
+		}
+
+		err := repl.Evaluate(text.String())
+		if err != nil {
+			fmt.Fprintln(output, FormatError(err))
+		}
+
+		text.Reset()
+		fmt.Fprint(output, "> ")
+	}
+
+	fmt.Fprintln(output)
+
+	return scanner.Err()
+}
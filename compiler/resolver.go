@@ -0,0 +1,156 @@
+package compiler
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ModuleResolver parses the given sources and, on demand, the modules they import, looking them up
+// as <root>/<module>.bir, as every .bir file inside <root>/<module>/ or as the interface file
+// <root>/<module>.biri for each source root. The components of a dotted module path are nested
+// directories, a.b is looked up as <root>/a/b.
+type ModuleResolver struct {
+	files       fileSystem
+	sourceRoots []string
+	fileNames   []string
+	roots       []*Node
+	parsedFiles map[string]*Node
+	modules     map[string]bool
+
+	// errors of the files that don't parse, the other files are still loaded so their errors are
+	// reported too. The modules of those files can't be checked
+	parseErrors   []error
+	failedModules map[string]bool
+}
+
+func newModuleResolver(files fileSystem, sourceRoots []string) *ModuleResolver {
+	return &ModuleResolver{
+		files:       files,
+		sourceRoots: sourceRoots,
+		parsedFiles: make(map[string]*Node),
+		modules:     make(map[string]bool),
+
+		failedModules: make(map[string]bool),
+	}
+}
+
+func astModuleName(ast *Node) string {
+	return ast.left.left.left.token.tokenValue
+}
+
+func (this *ModuleResolver) addFile(fileName string) (error, *Node) {
+	key, err := this.files.key(fileName)
+	if err != nil {
+		return err, nil
+	}
+
+	if root, ok := this.parsedFiles[key]; ok {
+		return nil, root
+	}
+
+	err, root := parseFile(this.files, fileName)
+	if _, ok := asDiagnostics(err); ok {
+		this.parseErrors = append(this.parseErrors, err)
+
+		// without its module declaration nothing is known about the file
+		if root == nil {
+			this.parsedFiles[key] = nil
+			return nil, nil
+		}
+
+		this.failedModules[astModuleName(root)] = true
+	} else if err != nil {
+		return err, nil
+	}
+
+	this.parsedFiles[key] = root
+	this.fileNames = append(this.fileNames, fileName)
+	this.roots = append(this.roots, root)
+	this.modules[astModuleName(root)] = true
+
+	return nil, root
+}
+
+func (this *ModuleResolver) findModuleFiles(name string) []string {
+	for _, sourceRoot := range this.sourceRoots {
+		basePath := filepath.Join(sourceRoot, filepath.FromSlash(strings.ReplaceAll(name, ".", "/")))
+
+		if this.files.isFile(basePath + ".bir") {
+			return []string{basePath + ".bir"}
+		}
+
+		fileNames := this.files.glob(filepath.Join(basePath, "*.bir"))
+		if len(fileNames) > 0 {
+			return fileNames
+		}
+
+		if this.files.isFile(basePath + interfaceExtension) {
+			return []string{basePath + interfaceExtension}
+		}
+	}
+
+	return nil
+}
+
+func (this *ModuleResolver) addModule(name string) (error, bool) {
+	fileNames := this.findModuleFiles(name)
+	if fileNames == nil {
+		return nil, false
+	}
+
+	for _, fileName := range fileNames {
+		err, root := this.addFile(fileName)
+		if err != nil {
+			return err, false
+		}
+
+		if root == nil {
+			this.failedModules[name] = true
+			continue
+		}
+
+		if astModuleName(root) != name {
+			return fmt.Errorf("%s: declares module %s but was found as module %s", fileName, astModuleName(root), name), false
+		}
+	}
+
+	return nil, true
+}
+
+func (this *ModuleResolver) moduleNotFoundError(fileName string, pathNode *Node) error {
+	return fmt.Errorf(
+		"%s: Module not found: %s, line: %d, column: %d, searched in: %s",
+		fileName,
+		pathNode.token.tokenValue,
+		pathNode.token.line,
+		pathNode.token.column,
+		strings.Join(this.sourceRoots, ", "),
+	)
+}
+
+// resolve parses every module imported by the parsed sources until all imports are known
+func (this *ModuleResolver) resolve() error {
+	// roots grows while imports are resolved
+	for index := 0; index < len(this.roots); index++ {
+		for imp := this.roots[index].left.right; imp != nil; imp = imp.next {
+			pathNode := imp.left
+			importName := pathNode.token.tokenValue
+
+			if this.modules[importName] {
+				continue
+			}
+
+			err, found := this.addModule(importName)
+			if err != nil {
+				return err
+			}
+
+			if !found {
+				return this.moduleNotFoundError(this.fileNames[index], pathNode)
+			}
+		}
+	}
+
+	return nil
+}
@@ -1,4 +1,4 @@
-package main
+package compiler
 
 type Item[T any] struct {
 	value T
@@ -0,0 +1,155 @@
+package compiler
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+)
+
+const DefaultToolchain = "clang"
+const DefaultArchiver = "ar"
+
+var optimizationLevels = []string{"0", "1", "2", "3", "s", "z"}
+
+func (this *BuildOptions) toolchain() string {
+	if this.Compiler == "" {
+		return DefaultToolchain
+	}
+
+	return this.Compiler
+}
+
+func (this *BuildOptions) archiverTool() string {
+	if this.Archiver == "" {
+		return DefaultArchiver
+	}
+
+	return this.Archiver
+}
+
+// targetFlags select the target of both the compilation and the link
+func (this *BuildOptions) targetFlags() []string {
+	if this.Target == "" {
+		return nil
+	}
+
+	return []string{"--target=" + this.Target}
+}
+
+// clangFlags are the flags used to turn the ir of a module into assembly or an object
+func (this *BuildOptions) clangFlags() []string {
+	var flags []string
+	if this.Optimization != "" {
+		flags = append(flags, "-O"+this.Optimization)
+	}
+
+	flags = append(flags, this.targetFlags()...)
+
+	// objects of shared libraries can be loaded anywhere
+	if this.Library == LIBRARY_SHARED {
+		flags = append(flags, "-fPIC")
+	}
+
+	return append(flags, this.CompilerFlags...)
+}
+
+// linkFlags are the flags given to the toolchain after the objects to link
+func (this *BuildOptions) linkFlags() []string {
+	flags := append([]string{}, this.targetFlags()...)
+
+	for _, libraryPath := range this.LibraryPaths {
+		flags = append(flags, "-L"+libraryPath)
+	}
+
+	for _, library := range this.Libraries {
+		flags = append(flags, "-l"+library)
+	}
+
+	return append(flags, this.LinkerFlags...)
+}
+
+// providedByLibrary tells if a library given to the link provides the module of an interface
+// object, libraries are shipped with the interfaces of their modules so they are in the same
+// directory. Libraries given by name are looked for in the library paths
+func (this *BuildOptions) providedByLibrary(object string) bool {
+	directory := filepath.Clean(filepath.Dir(object))
+
+	for _, object := range this.Objects {
+		extension := filepath.Ext(object)
+		if (extension == ".a" || extension == ".so") && filepath.Clean(filepath.Dir(object)) == directory {
+			return true
+		}
+	}
+
+	for _, libraryPath := range this.LibraryPaths {
+		if filepath.Clean(libraryPath) != directory {
+			continue
+		}
+
+		for _, library := range this.Libraries {
+			for _, fileName := range []string{"lib" + library + ".a", "lib" + library + ".so"} {
+				_, err := os.Stat(filepath.Join(libraryPath, fileName))
+				if err == nil {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+var dataLayoutPattern = regexp.MustCompile(`target datalayout = "([^"]*)"`)
+
+// Resolve checks the settings and completes the ones that are not given, the data layout of the
+// target is asked to the toolchain so the generated ir doesn't depend on the defaults of clang
+func (this *BuildOptions) Resolve() error {
+	if this.Optimization != "" && findString(optimizationLevels, this.Optimization) < 0 {
+		return fmt.Errorf("Invalid optimization level: %s, expected one of: 0, 1, 2, 3, s, z", this.Optimization)
+	}
+
+	if this.Library != "" && findString(libraryKinds, this.Library) < 0 {
+		return fmt.Errorf("Invalid library kind: %s, expected one of: static, shared", this.Library)
+	}
+
+	if this.Backend != "" && findString(backendKinds, this.Backend) < 0 {
+		return fmt.Errorf("Invalid backend: %s, expected one of: llvm, c, wasm", this.Backend)
+	}
+
+	if this.Backend == BACKEND_WASM && this.Library != "" {
+		return fmt.Errorf("Libraries can't be built with the wasm backend, every module is already a wasm module")
+	}
+
+	if this.ProgramName == "" {
+		this.ProgramName = this.defaultOutput()
+	}
+
+	if this.Target == "" || this.DataLayout != "" {
+		return nil
+	}
+
+	arguments := append(this.targetFlags(), "-S", "-emit-llvm", "-x", "c", "-", "-o", "-")
+
+	var output bytes.Buffer
+	cmd := exec.Command(this.toolchain(), arguments...)
+	cmd.Stdin = bytes.NewReader(nil)
+	cmd.Stdout = &output
+	cmd.Stderr = this.stderr()
+
+	err := cmd.Run()
+	if err != nil {
+		return fmt.Errorf("Can't get the data layout of target %s: %w", this.Target, err)
+	}
+
+	match := dataLayoutPattern.FindSubmatch(output.Bytes())
+	if match == nil {
+		return fmt.Errorf("Can't get the data layout of target %s, set it with -datalayout", this.Target)
+	}
+
+	this.DataLayout = string(match[1])
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package compiler
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProvidedByLibrary(t *testing.T) {
+	directory := t.TempDir()
+	other := t.TempDir()
+
+	err := os.WriteFile(filepath.Join(directory, "libshapes.a"), nil, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	object := filepath.Join(directory, "shapes.obj")
+
+	tests := []struct {
+		name     string
+		options  BuildOptions
+		provided bool
+	}{
+		{"no library", BuildOptions{}, false},
+		{"library object", BuildOptions{Objects: []string{filepath.Join(directory, "libshapes.a")}}, true},
+		{"library in another directory", BuildOptions{Objects: []string{filepath.Join(other, "libshapes.a")}}, false},
+		{"object that is not a library", BuildOptions{Objects: []string{filepath.Join(directory, "helpers.o")}}, false},
+		{"library by name", BuildOptions{LibraryPaths: []string{directory}, Libraries: []string{"shapes"}}, true},
+		{"missing library by name", BuildOptions{LibraryPaths: []string{directory}, Libraries: []string{"m"}}, false},
+		{"library path of another directory", BuildOptions{LibraryPaths: []string{other}, Libraries: []string{"shapes"}}, false},
+	}
+
+	for _, test := range tests {
+		if test.options.providedByLibrary(object) != test.provided {
+			t.Errorf("%s: expected provided to be %t", test.name, test.provided)
+		}
+	}
+}
@@ -0,0 +1,942 @@
+package compiler
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// WatCompiler generates the WebAssembly text format of a module. Every bir module becomes a wasm
+// module importing the functions of the modules it uses by their name, and C functions from env.
+// The modules share the memory of the host, env.memory, where the instances are allocated from
+// env.heap, the first free address, and are never freed.
+//
+// Some programs are rejected with an error instead of being compiled. Root variables, and with
+// them the variables of other modules, are not compiled by Compiler either. Interfaces are not
+// supported. The address of a variable can't be taken, because wasm locals have no address.
+type WatCompiler struct {
+	asts       []*Node
+	moduleName *string
+	// exported functions keep their name, like with Compiler
+	exportC    bool
+	output     io.Writer
+	code       string
+	// imports of the called functions, by name so they are imported once
+	imports     map[string]bool
+	importLines []string
+	externs     externDeclarations
+	// string literals, they are copied to the heap when the module starts
+	literals    []string
+	// body of the function being generated
+	body        strings.Builder
+	indentation int
+	locals      []string
+	localNames  map[*Symbol]string
+	usedNames   map[string]bool
+	labels      int
+}
+
+func newWatCompiler(asts []*Node, moduleName string, options *BuildOptions) *WatCompiler {
+	return &WatCompiler{
+		asts:       asts,
+		moduleName: &moduleName,
+		exportC:    options.ExportC,
+		output:     options.stderr(),
+		imports:    make(map[string]bool),
+		externs:    make(externDeclarations),
+	}
+}
+
+func (this *WatCompiler) module() string {
+	return *this.moduleName
+}
+
+func (this *WatCompiler) sources() []*Node {
+	return this.asts
+}
+
+func (this *WatCompiler) setOutput(output io.Writer) {
+	this.output = output
+}
+
+func (this *WatCompiler) extension() string {
+	return ".wat"
+}
+
+func (this *WatCompiler) Code() string {
+	return this.code
+}
+
+func (this *WatCompiler) functionName(symbol *Symbol) string {
+	return objectName(symbol, this.exportC)
+}
+
+// watString writes bytes as a string of the text format
+func watString(text string) string {
+	var builder strings.Builder
+	builder.WriteString("\"")
+
+	for index := 0; index < len(text); index++ {
+		character := text[index]
+
+		if character == '"' || character == '\\' || character < ' ' || character > '~' {
+			fmt.Fprintf(&builder, "\\%02x", character)
+		} else {
+			builder.WriteByte(character)
+		}
+	}
+
+	builder.WriteString("\"")
+
+	return builder.String()
+}
+
+// valueType follows the lowering of convertType, addresses are 32 bit and bools are i32 values
+// stored in a byte
+func (this *WatCompiler) valueType(birType *SymbolType) (error, string) {
+	if birType.kind == TYPE_INTERFACE {
+		return fmt.Errorf("Interface %s is not supported by the wasm backend", birType.name), ""
+	}
+
+	switch lowerType(birType) {
+	case LOWERED_INT:
+		return nil, "i64"
+	case LOWERED_FLOAT:
+		return nil, "f64"
+	case LOWERED_VOID:
+		return nil, ""
+	}
+
+	return nil, "i32"
+}
+
+func memorySize(birType *SymbolType) int {
+	switch lowerType(birType) {
+	case LOWERED_INT, LOWERED_FLOAT:
+		return 8
+	case LOWERED_BOOL:
+		return 1
+	}
+
+	return 4
+}
+
+func loadInstruction(birType *SymbolType) string {
+	switch lowerType(birType) {
+	case LOWERED_INT:
+		return "i64.load"
+	case LOWERED_FLOAT:
+		return "f64.load"
+	case LOWERED_BOOL:
+		return "i32.load8_u"
+	}
+
+	return "i32.load"
+}
+
+func storeInstruction(birType *SymbolType) string {
+	switch lowerType(birType) {
+	case LOWERED_INT:
+		return "i64.store"
+	case LOWERED_FLOAT:
+		return "f64.store"
+	case LOWERED_BOOL:
+		return "i32.store8"
+	}
+
+	return "i32.store"
+}
+
+// structLayout returns the offsets of the fields of a struct and its size, fields are aligned to
+// their size like in C
+func structLayout(symbol *Symbol) ([]int, int) {
+	var offsets []int
+	size := 0
+	for _, fieldType := range structFields(symbol) {
+		fieldSize := memorySize(fieldType)
+		size = (size + fieldSize - 1) / fieldSize * fieldSize
+
+		offsets = append(offsets, size)
+		size += fieldSize
+	}
+
+	return offsets, (size + 7) / 8 * 8
+}
+
+func fieldOffset(symbol *Symbol, fieldName string) (error, int, *SymbolType) {
+	offsets, _ := structLayout(symbol)
+
+	index := 0
+	for field := symbol.node.right; field != nil; field = field.next {
+		if field.token.tokenValue == fieldName {
+			return nil, offsets[index], &field.symbol.simbolType
+		}
+
+		index++
+	}
+
+	return fmt.Errorf("can't find member"), 0, nil
+}
+
+func (this *WatCompiler) signature(symbol *Symbol, named bool) (error, string) {
+	signature := symbol.simbolType.signature
+
+	var parts []string
+	if signature.self != nil {
+		if named {
+			parts = append(parts, "(param $this i32)")
+		} else {
+			parts = append(parts, "(param i32)")
+		}
+	}
+
+	for _, parameter := range signature.parameters {
+		err, parameterType := this.valueType(parameter.paramType)
+		if err != nil {
+			return err, ""
+		}
+
+		if named {
+			parts = append(parts, "(param $"+parameter.name+" "+parameterType+")")
+		} else {
+			parts = append(parts, "(param "+parameterType+")")
+		}
+	}
+
+	err, returnType := this.valueType(signature.returnType)
+	if err != nil {
+		return err, ""
+	}
+
+	if returnType != "" {
+		parts = append(parts, "(result "+returnType+")")
+	}
+
+	return nil, strings.Join(parts, " ")
+}
+
+// declare imports the functions of other modules and C functions the module calls
+func (this *WatCompiler) declare(symbol *Symbol) error {
+	isExternal := symbol.module != nil && *symbol.module != *this.moduleName
+	if symbol.linkName == "" && !isExternal {
+		return nil
+	}
+
+	if symbol.linkName != "" {
+		err, declared := this.externs.add(symbol)
+		if err != nil || declared {
+			return err
+		}
+	}
+
+	name := this.functionName(symbol)
+	if this.imports[name] {
+		return nil
+	}
+
+	err, signature := this.signature(symbol, false)
+	if err != nil {
+		return err
+	}
+
+	importModule := "env"
+	if symbol.linkName == "" {
+		importModule = *symbol.module
+	}
+
+	this.imports[name] = true
+	this.importLines = append(this.importLines, fmt.Sprintf("(import %s %s (func $%s %s))", watString(importModule), watString(name), name, signature))
+
+	return nil
+}
+
+// addLocal declares a local of the function, bir scopes can reuse a name so it is made unique
+func (this *WatCompiler) addLocal(name string, localType string) string {
+	localName := name
+	for suffix := 1; this.usedNames[localName]; suffix++ {
+		localName = name + "." + strconv.Itoa(suffix)
+	}
+
+	this.usedNames[localName] = true
+	this.locals = append(this.locals, "(local $"+localName+" "+localType+")")
+
+	return localName
+}
+
+func (this *WatCompiler) writeLine(format string, arguments ...any) {
+	this.body.WriteString(strings.Repeat("\t", this.indentation))
+	fmt.Fprintf(&this.body, format, arguments...)
+	this.body.WriteString("\n")
+}
+
+func (this *WatCompiler) walkBinaryExpression(node *Node) (error, string) {
+	err, leftValue := this.walkExpression(node.left)
+	if err != nil {
+		return err, ""
+	}
+
+	err, rightValue := this.walkExpression(node.right)
+	if err != nil {
+		return err, ""
+	}
+
+	operandType := node.left.symbolType
+	tokenType := node.token.tokenType
+
+	// pointer arithmetic moves by elements, not bytes
+	if operandType.kind == TYPE_POINTER && node.right.symbolType.kind != TYPE_POINTER {
+		offset := fmt.Sprintf("(i32.mul (i32.wrap_i64 %s) (i32.const %d))", rightValue, memorySize(operandType.element))
+
+		if tokenType == TOKEN_MINUS {
+			return nil, "(i32.sub " + leftValue + " " + offset + ")"
+		}
+
+		return nil, "(i32.add " + leftValue + " " + offset + ")"
+	}
+
+	err, prefix := this.valueType(operandType)
+	if err != nil {
+		return err, ""
+	}
+
+	isFloat := prefix == "f64"
+	isInt := prefix == "i64"
+
+	// the comparisons of integers are signed and the ones of addresses unsigned
+	sign := ""
+	if isInt {
+		sign = "_s"
+	} else if !isFloat {
+		sign = "_u"
+	}
+
+	instructions := map[int]string{
+		TOKEN_PLUS:          "add",
+		TOKEN_MINUS:         "sub",
+		TOKEN_MULTIPLY:      "mul",
+		TOKEN_AND:           "and",
+		TOKEN_OR:            "or",
+		TOKEN_EQUAL:         "eq",
+		TOKEN_DIFFERENT:     "ne",
+		TOKEN_DIVIDE:        "div" + sign,
+		TOKEN_GREATER:       "gt" + sign,
+		TOKEN_GREATER_EQUAL: "ge" + sign,
+		TOKEN_LESS:          "lt" + sign,
+		TOKEN_LESS_EQUAL:    "le" + sign,
+	}
+
+	instruction, ok := instructions[tokenType]
+	if !ok {
+		return nodeDiagnostic(node, "Invalid operation"), ""
+	}
+
+	return nil, "(" + prefix + "." + instruction + " " + leftValue + " " + rightValue + ")"
+}
+
+func (this *WatCompiler) walkCast(node *Node) (error, string) {
+	err, value := this.walkExpression(node.left)
+	if err != nil {
+		return err, ""
+	}
+
+	err, fromType := this.valueType(node.left.symbolType)
+	if err != nil {
+		return err, ""
+	}
+
+	err, toType := this.valueType(node.symbolType)
+	if err != nil {
+		return err, ""
+	}
+
+	conversions := map[string]string{
+		"i64 f64": "f64.convert_i64_s",
+		"f64 i64": "i64.trunc_f64_s",
+		"i32 i64": "i64.extend_i32_u",
+		"i64 i32": "i32.wrap_i64",
+	}
+
+	conversion, ok := conversions[fromType+" "+toType]
+	if !ok {
+		return nil, value
+	}
+
+	return nil, "(" + conversion + " " + value + ")"
+}
+
+func (this *WatCompiler) walkArguments(argumentsNode *Node, arguments []string) (error, string) {
+	for argument := argumentsNode.right; argument != nil; argument = argument.next {
+		err, argumentValue := this.walkExpression(argument)
+		if err != nil {
+			return err, ""
+		}
+
+		arguments = append(arguments, argumentValue)
+	}
+
+	return nil, strings.Join(arguments, " ")
+}
+
+// walkConstructor allocates an instance on the heap, the fields are initialized before init is
+// called
+func (this *WatCompiler) walkConstructor(symbol *Symbol, argumentsNode *Node) (error, string) {
+	_, size := structLayout(symbol)
+
+	name := this.addLocal("instance", "i32")
+	instance := "(local.get $" + name + ")"
+
+	parts := []string{
+		fmt.Sprintf("(local.set $%s (call $bir:alloc (i32.const %d)))", name, size),
+		fmt.Sprintf("(memory.fill %s (i32.const 0) (i32.const %d))", instance, size),
+	}
+
+	for field := symbol.node.right; field != nil; field = field.next {
+		if field.symbol == nil || field.right == nil {
+			continue
+		}
+
+		err, fieldValue := this.walkExpression(field.right)
+		if err != nil {
+			return err, ""
+		}
+
+		err, offset, fieldType := fieldOffset(symbol, field.token.tokenValue)
+		if err != nil {
+			return err, ""
+		}
+
+		parts = append(parts, fmt.Sprintf("(%s offset=%d %s %s)", storeInstruction(fieldType), offset, instance, fieldValue))
+	}
+
+	if initSymbol, ok := (*symbol.node.symbolTable)["init"]; ok {
+		err := this.declare(initSymbol)
+		if err != nil {
+			return err, ""
+		}
+
+		err, arguments := this.walkArguments(argumentsNode, []string{instance})
+		if err != nil {
+			return err, ""
+		}
+
+		parts = append(parts, "(call $"+this.functionName(initSymbol)+" "+arguments+")")
+	}
+
+	parts = append(parts, instance)
+
+	return nil, "(block (result i32) " + strings.Join(parts, " ") + ")"
+}
+
+func (this *WatCompiler) walkCall(node *Node) (error, string) {
+	callee := node.left
+
+	var symbol *Symbol
+	var arguments []string
+
+	if callee.nodeType == NODE_VARIABLE {
+		symbol = callee.symbol
+	} else if callee.nodeType == NODE_MEMBER_ACCESS && callee.left.symbolType.kind == TYPE_MODULE {
+		// member of an imported module
+		symbol = callee.symbol
+	} else if callee.nodeType == NODE_MEMBER_ACCESS {
+		structType := callee.left.symbolType
+		if structType.kind == TYPE_INTERFACE {
+			return nodeDiagnostic(callee, "Calls through interface %s are not supported by the wasm backend", structType.name), ""
+		}
+
+		method, ok := (*structType.symbol.node.symbolTable)[callee.token.tokenValue]
+		if !ok || method.simbolType.kind != TYPE_FUNCTION {
+			return tokenDiagnostic(callee.token, "Struct %s has no method %s", structType.name, callee.token.tokenValue), ""
+		}
+
+		err, instance := this.walkExpression(callee.left)
+		if err != nil {
+			return err, ""
+		}
+
+		symbol = method
+		arguments = append(arguments, instance)
+	} else {
+		return nodeDiagnostic(callee, "Only functions can be called"), ""
+	}
+
+	if symbol.simbolType.kind == TYPE_STRUCT {
+		return this.walkConstructor(symbol, node.right)
+	}
+
+	err := this.declare(symbol)
+	if err != nil {
+		return err, ""
+	}
+
+	err, argumentList := this.walkArguments(node.right, arguments)
+	if err != nil {
+		return err, ""
+	}
+
+	if argumentList == "" {
+		return nil, "(call $" + this.functionName(symbol) + ")"
+	}
+
+	return nil, "(call $" + this.functionName(symbol) + " " + argumentList + ")"
+}
+
+// walkAddress returns the address of a field or of the value a pointer points to, locals are not
+// in the memory so they have no address
+func (this *WatCompiler) walkAddress(node *Node) (error, string, int) {
+	if node.nodeType == NODE_DEREFERENCE {
+		err, address := this.walkExpression(node.left)
+		return err, address, 0
+	}
+
+	if node.nodeType == NODE_MEMBER_ACCESS && node.left.symbolType.kind == TYPE_STRUCT {
+		err, instance := this.walkExpression(node.left)
+		if err != nil {
+			return err, "", 0
+		}
+
+		err, offset, _ := fieldOffset(node.left.symbolType.symbol, node.token.tokenValue)
+
+		return err, instance, offset
+	}
+
+	if node.nodeType == NODE_MEMBER_ACCESS && node.left.symbolType.kind == TYPE_INTERFACE {
+		return nodeDiagnostic(node, "Interface %s is not supported by the wasm backend", node.left.symbolType.name), "", 0
+	}
+
+	if node.nodeType == NODE_MEMBER_ACCESS {
+		return nodeDiagnostic(node, "Variables of module %s are not supported by the wasm backend", node.left.symbolType.name), "", 0
+	}
+
+	return nodeDiagnostic(node, "The address of variables can't be taken with the wasm backend"), "", 0
+}
+
+func (this *WatCompiler) localName(variable *Node) (error, string) {
+	name, ok := this.localNames[variable.symbol]
+	if !ok {
+		return nodeDiagnostic(variable, "Variable %s is not a local, root variables are not supported by the wasm backend", variable.symbol.name), ""
+	}
+
+	return nil, name
+}
+
+func (this *WatCompiler) walkExpression(node *Node) (error, string) {
+	switch node.nodeType {
+	case NODE_INT:
+		_, err := strconv.ParseInt(node.token.tokenValue, 10, 64)
+		if err != nil {
+			return err, ""
+		}
+
+		return nil, "(i64.const " + node.token.tokenValue + ")"
+	case NODE_FLOAT:
+		floatValue, err := strconv.ParseFloat(node.token.tokenValue, 64)
+		if err != nil {
+			return err, ""
+		}
+
+		return nil, "(f64.const " + strconv.FormatFloat(floatValue, 'g', -1, 64) + ")"
+	case NODE_STRING:
+		index := findString(this.literals, node.token.tokenValue)
+		if index < 0 {
+			index = len(this.literals)
+			this.literals = append(this.literals, node.token.tokenValue)
+		}
+
+		return nil, fmt.Sprintf("(global.get $string%d)", index)
+	case NODE_BOOL:
+		if node.token.tokenType == TOKEN_TRUE {
+			return nil, "(i32.const 1)"
+		}
+
+		return nil, "(i32.const 0)"
+	case NODE_VARIABLE:
+		err, name := this.localName(node)
+		if err != nil {
+			return err, ""
+		}
+
+		return nil, "(local.get $" + name + ")"
+	case NODE_NOT:
+		err, value := this.walkExpression(node.left)
+		if err != nil {
+			return err, ""
+		}
+
+		return nil, "(i32.eqz " + value + ")"
+	case NODE_BINARY_EXPRESSION:
+		return this.walkBinaryExpression(node)
+	case NODE_CAST:
+		return this.walkCast(node)
+	case NODE_ADDRESS:
+		err, address, offset := this.walkAddress(node.left)
+		if err != nil {
+			return err, ""
+		}
+
+		if offset == 0 {
+			return nil, address
+		}
+
+		return nil, fmt.Sprintf("(i32.add %s (i32.const %d))", address, offset)
+	case NODE_DEREFERENCE, NODE_MEMBER_ACCESS:
+		err, address, offset := this.walkAddress(node)
+		if err != nil {
+			return err, ""
+		}
+
+		return nil, fmt.Sprintf("(%s offset=%d %s)", loadInstruction(node.symbolType), offset, address)
+	case NODE_CALL:
+		return this.walkCall(node)
+	}
+
+	return nodeDiagnostic(node, "Can't compile %s", nodeStrings[node.nodeType]), ""
+}
+
+func (this *WatCompiler) zeroValue(birType *SymbolType) (error, string) {
+	err, valueType := this.valueType(birType)
+	if err != nil {
+		return err, ""
+	}
+
+	return nil, "(" + valueType + ".const 0)"
+}
+
+func (this *WatCompiler) walk(node *Node) error {
+	for ; node != nil; node = node.next {
+		switch node.nodeType {
+		case NODE_RETURN:
+			if node.left == nil {
+				this.writeLine("(return)")
+				continue
+			}
+
+			err, returnValue := this.walkExpression(node.left)
+			if err != nil {
+				return err
+			}
+
+			this.writeLine("(return %s)", returnValue)
+		case NODE_ASSIGNMENT:
+			err, assignmentValue := this.walkExpression(node.right)
+			if err != nil {
+				return err
+			}
+
+			if node.left.nodeType == NODE_VARIABLE {
+				err, name := this.localName(node.left)
+				if err != nil {
+					return err
+				}
+
+				this.writeLine("(local.set $%s %s)", name, assignmentValue)
+				continue
+			}
+
+			err, address, offset := this.walkAddress(node.left)
+			if err != nil {
+				return err
+			}
+
+			this.writeLine("(%s offset=%d %s %s)", storeInstruction(node.left.symbolType), offset, address, assignmentValue)
+		case NODE_VARIABLE_DECLARATION:
+			err, valueType := this.valueType(&node.symbol.simbolType)
+			if err != nil {
+				return err
+			}
+
+			// variables start at zero like in the interpreter, also when a loop declares them again
+			err, initValue := this.zeroValue(&node.symbol.simbolType)
+			if err != nil {
+				return err
+			}
+
+			if node.right != nil {
+				err, initValue = this.walkExpression(node.right)
+				if err != nil {
+					return err
+				}
+			}
+
+			name := this.addLocal(node.symbol.name, valueType)
+			this.localNames[node.symbol] = name
+
+			this.writeLine("(local.set $%s %s)", name, initValue)
+		case NODE_IF:
+			err, condition := this.walkExpression(node.left)
+			if err != nil {
+				return err
+			}
+
+			this.writeLine("(if %s", condition)
+			this.indentation++
+			this.writeLine("(then")
+
+			err = this.walkBlock(node.right.left)
+			if err != nil {
+				return err
+			}
+
+			this.writeLine(")")
+
+			if node.right.right != nil {
+				this.writeLine("(else")
+
+				err = this.walkBlock(node.right.right)
+				if err != nil {
+					return err
+				}
+
+				this.writeLine(")")
+			}
+
+			this.indentation--
+			this.writeLine(")")
+		case NODE_WHILE:
+			err, condition := this.walkExpression(node.left)
+			if err != nil {
+				return err
+			}
+
+			this.labels++
+			label := strconv.Itoa(this.labels)
+
+			this.writeLine("(block $break%s", label)
+			this.indentation++
+			this.writeLine("(loop $continue%s", label)
+			this.indentation++
+			this.writeLine("(br_if $break%s (i32.eqz %s))", label, condition)
+
+			err = this.walk(node.right.left)
+			if err != nil {
+				return err
+			}
+
+			this.writeLine("(br $continue%s)", label)
+			this.indentation--
+			this.writeLine(")")
+			this.indentation--
+			this.writeLine(")")
+		case NODE_UNSAFE:
+			err := this.walk(node.left)
+			if err != nil {
+				return err
+			}
+		default:
+			err, value := this.walkExpression(node)
+			if err != nil {
+				return err
+			}
+
+			if node.symbolType != nil && lowerType(node.symbolType) != LOWERED_VOID {
+				value = "(drop " + value + ")"
+			}
+
+			this.writeLine("%s", value)
+		}
+	}
+
+	return nil
+}
+
+func (this *WatCompiler) walkBlock(node *Node) error {
+	this.indentation++
+	err := this.walk(node)
+	this.indentation--
+
+	return err
+}
+
+func (this *WatCompiler) walkFunction(node *Node) (error, string) {
+	symbol := node.left.symbol
+
+	err, signature := this.signature(symbol, true)
+	if err != nil {
+		return err, ""
+	}
+
+	this.body.Reset()
+	this.indentation = 2
+	this.locals = nil
+	this.localNames = make(map[*Symbol]string)
+	this.usedNames = make(map[string]bool)
+
+	if symbol.simbolType.signature.self != nil {
+		this.localNames[(*node.left.symbolTable)["this"]] = "this"
+		this.usedNames["this"] = true
+	}
+
+	for _, parameter := range symbol.simbolType.signature.parameters {
+		this.localNames[parameter.node.symbol] = parameter.name
+		this.usedNames[parameter.name] = true
+	}
+
+	err = this.walk(node.right)
+	if err != nil {
+		return err, ""
+	}
+
+	// functions returning a value end with a return, the end of the body is never reached
+	if lowerType(symbol.simbolType.signature.returnType) != LOWERED_VOID {
+		this.writeLine("(unreachable)")
+	}
+
+	name := this.functionName(symbol)
+
+	header := "(func $" + name
+	if symbol.exported || symbol.name == "main" {
+		header += " (export " + watString(name) + ")"
+	}
+
+	if signature != "" {
+		header += " " + signature
+	}
+
+	var builder strings.Builder
+	builder.WriteString("\t" + header + "\n")
+
+	for _, local := range this.locals {
+		builder.WriteString("\t\t" + local + "\n")
+	}
+
+	builder.WriteString(this.body.String())
+	builder.WriteString("\t)\n")
+
+	return nil, builder.String()
+}
+
+// walkRoot returns the functions and methods of a file
+func (this *WatCompiler) walkRoot(node *Node) (error, []string) {
+	var functions []string
+	for ; node != nil; node = node.next {
+		if node.nodeType == NODE_IMPLEMENT {
+			err, methods := this.walkRoot(node.right)
+			if err != nil {
+				return err, nil
+			}
+
+			functions = append(functions, methods...)
+		} else if node.nodeType == NODE_FUNCTION || node.nodeType == NODE_CONSTRUCTOR {
+			err, function := this.walkFunction(node)
+			if err != nil {
+				return inSymbolFile(err, node.left.symbol), nil
+			}
+
+			functions = append(functions, function)
+		} else if node.nodeType == NODE_VARIABLE_DECLARATION {
+			return inSymbolFile(nodeDiagnostic(node, "Root variables are not supported by the wasm backend"), node.symbol), nil
+		}
+	}
+
+	return nil, functions
+}
+
+// allocFunction returns the allocator of the instances, it takes them from the heap and grows the
+// memory when the heap reaches its end. The colon keeps its name apart from the mangled names
+const allocFunction = `	(func $bir:alloc (param $size i32) (result i32)
+		(local $address i32)
+		(local.set $address (i32.and (i32.add (global.get $bir.heap) (i32.const 7)) (i32.const -8)))
+		(global.set $bir.heap (i32.add (local.get $address) (local.get $size)))
+		(if (i32.gt_u (global.get $bir.heap) (i32.shl (memory.size) (i32.const 16)))
+			(then
+				(if (i32.eq (memory.grow (i32.sub (i32.add (i32.shr_u (global.get $bir.heap) (i32.const 16)) (i32.const 1)) (memory.size))) (i32.const -1))
+					(then
+						(unreachable)
+					)
+				)
+			)
+		)
+		(local.get $address)
+	)
+`
+
+func (this *WatCompiler) Generate() error {
+	this.imports = make(map[string]bool)
+	this.importLines = nil
+	this.externs = make(externDeclarations)
+	this.literals = nil
+	this.labels = 0
+
+	var functions []string
+	for _, ast := range this.asts {
+		err, rootFunctions := this.walkRoot(ast.right)
+		if err != nil {
+			return err
+		}
+
+		functions = append(functions, rootFunctions...)
+	}
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, ";; generated by bir from module %s, do not edit\n\n", *this.moduleName)
+	builder.WriteString("(module\n")
+	builder.WriteString("\t(import \"env\" \"memory\" (memory 1))\n")
+	builder.WriteString("\t(import \"env\" \"heap\" (global $bir.heap (mut i32)))\n")
+
+	for _, importLine := range this.importLines {
+		builder.WriteString("\t" + importLine + "\n")
+	}
+
+	builder.WriteString("\n")
+
+	for index, literal := range this.literals {
+		fmt.Fprintf(&builder, "\t(global $string%d (mut i32) (i32.const 0))\n", index)
+		fmt.Fprintf(&builder, "\t(data $string%d %s)\n", index, watString(literal+"\x00"))
+	}
+
+	if len(this.literals) > 0 {
+		builder.WriteString("\n")
+	}
+
+	builder.WriteString(allocFunction)
+
+	// the literals are copied to the heap, the data of the modules can't be placed at fixed
+	// addresses of the shared memory
+	if len(this.literals) > 0 {
+		builder.WriteString("\n\t(func $bir:start\n")
+
+		for index, literal := range this.literals {
+			size := len(literal) + 1
+
+			fmt.Fprintf(&builder, "\t\t(global.set $string%d (call $bir:alloc (i32.const %d)))\n", index, size)
+			fmt.Fprintf(&builder, "\t\t(memory.init $string%d (global.get $string%d) (i32.const 0) (i32.const %d))\n", index, index, size)
+			fmt.Fprintf(&builder, "\t\t(data.drop $string%d)\n", index)
+		}
+
+		builder.WriteString("\t)\n\t(start $bir:start)\n")
+	}
+
+	for _, function := range functions {
+		builder.WriteString("\n" + function)
+	}
+
+	builder.WriteString(")\n")
+
+	this.code = builder.String()
+
+	return nil
+}
+
+func (this *WatCompiler) cacheFlags() []string {
+	return []string{BACKEND_WASM, strconv.FormatBool(this.exportC)}
+}
+
+func (this *WatCompiler) EmitAssembly(outputFileName string) error {
+	return fmt.Errorf("The wasm backend only writes the text format, emit the ir stage")
+}
+
+func (this *WatCompiler) EmitObject(outputFileName string) error {
+	return fmt.Errorf("The wasm backend only writes the text format, emit the ir stage")
+}
+
+// Compile writes the text format of the module, the host assembles and links the modules
+func (this *WatCompiler) Compile(outputFileName string) error {
+	err := this.Generate()
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(outputFileName, []byte(this.code), 0644)
+}
@@ -0,0 +1,38 @@
+package compiler
+
+import "testing"
+
+func TestWatUnsupportedConstructs(t *testing.T) {
+	tests := []struct {
+		name    string
+		sources map[string]string
+		error   string
+	}{
+		{"root variable", map[string]string{"main.bir": "module main\nconst COUNT = 1\nfunction main(): int {\n\treturn 0\n}\n"}, "main.bir:2:7: Root variables are not supported by the wasm backend"},
+		{"module variable", map[string]string{
+			"a.bir":    "module a\nexport const COUNT = 1\n",
+			"main.bir": "module main\nimport a\nfunction main(): int {\n\treturn a.COUNT\n}\n",
+		}, "main.bir:4:9: Variables of module a are not supported by the wasm backend"},
+		{"address of variable", map[string]string{"main.bir": "module main\nfunction main(): int {\n\tvar a = 1\n\tunsafe {\n\t\tvar p = &a\n\t}\n\treturn 0\n}\n"}, "main.bir:5:12: The address of variables can't be taken with the wasm backend"},
+	}
+
+	for _, test := range tests {
+		files := newMemoryFiles(test.sources)
+		err, graph := checkFiles(files, files.fileNames(), &BuildOptions{})
+		if err != nil {
+			t.Fatalf("%s: check failed: %s", test.name, err)
+		}
+
+		err = newBackend(graph.modules["main"], "main", &BuildOptions{Backend: BACKEND_WASM}).Generate()
+		if err == nil {
+			t.Errorf("%s: generate succeeded", test.name)
+			continue
+		}
+
+		// the errors of the backend are diagnostics, with the file and the span of the construct
+		diagnostics, ok := asDiagnostics(err)
+		if !ok || len(diagnostics) != 1 || diagnostics[0].Error() != test.error {
+			t.Errorf("%s: got %v, expected %s", test.name, err, test.error)
+		}
+	}
+}
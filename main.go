@@ -1,76 +1,296 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
+	"io/fs"
 	"os"
-	"os/exec"
+	"strings"
+
+	"bir/compiler"
 )
 
-func parseFile(fileName string) (error, *Node) {
-	data, err := os.ReadFile(fileName)
+const usageText = `Usage: %s <command> [flags] [./file1.bir ./file2.bir ...]
+
+Commands:
+  check    parse and type check the sources
+  build    compile and link the sources into an executable
+  run      build the sources to a temporary location and execute them
+  emit     write the intermediate artifacts of the sources
+  repl     evaluate statements and expressions interactively
+
+When no sources are given, the project is read from %s.
+Run '%s <command> -h' for the flags of a command.
+`
+
+func usage() {
+	programName := os.Args[0]
+	fmt.Fprintf(os.Stderr, usageText, programName, compiler.ManifestFileName, programName)
+}
+
+func newCommand(name string, arguments string) *flag.FlagSet {
+	command := flag.NewFlagSet(name, flag.ExitOnError)
+	command.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s [flags] %s\n", os.Args[0], name, arguments)
+		command.PrintDefaults()
+	}
+
+	return command
+}
+
+type stringList []string
+
+func (this *stringList) String() string {
+	return strings.Join(*this, ",")
+}
+
+func (this *stringList) Set(value string) error {
+	*this = append(*this, value)
+	return nil
+}
+
+type projectFlags struct {
+	manifest       *string
+	buildDirectory *string
+	sourceRoots    *stringList
+	jobs           *int
+	errorLimit     *int
+}
+
+func addProjectFlags(command *flag.FlagSet) *projectFlags {
+	flags := &projectFlags{
+		manifest:       command.String("manifest", compiler.ManifestFileName, "project manifest used when no sources are given"),
+		buildDirectory: command.String("build-dir", "", "directory for objects and the compilation cache (default \""+compiler.DefaultBuildDirectory+"\")"),
+		sourceRoots:    &stringList{},
+		jobs:           command.Int("j", compiler.DefaultJobs, "number of modules checked and compiled at the same time"),
+		errorLimit:     command.Int("error-limit", compiler.DefaultErrorLimit, "number of errors reported, 0 reports all of them"),
+	}
+
+	command.Var(flags.sourceRoots, "I", "source root searched for imported modules, can be repeated in addition to the directories of the sources")
+
+	return flags
+}
+
+// loadProject returns the sources given on the command line, or the sources of the manifest when
+// there are none, together with the build options they describe.
+func loadProject(command *flag.FlagSet, flags *projectFlags) (error, []string, *compiler.BuildOptions) {
+	options := &compiler.BuildOptions{
+		BuildDirectory: compiler.DefaultBuildDirectory,
+	}
+
+	fileNames := command.Args()
+
+	if len(fileNames) == 0 {
+		err, manifest := compiler.LoadManifest(*flags.manifest)
+		if errors.Is(err, fs.ErrNotExist) {
+			command.Usage()
+			return fmt.Errorf("No sources given and no %s found", *flags.manifest), nil, nil
+		}
+
+		if err != nil {
+			return err, nil, nil
+		}
+
+		options = manifest.BuildOptions()
+	}
+
+	if *flags.buildDirectory != "" {
+		options.BuildDirectory = *flags.buildDirectory
+	}
+
+	options.SourceRoots = append(options.SourceRoots, *flags.sourceRoots...)
+	options.Jobs = *flags.jobs
+	options.ErrorLimit = *flags.errorLimit
+	options.Stdout = os.Stdout
+	options.Stderr = os.Stderr
+	options.Stdin = os.Stdin
+
+	return nil, fileNames, options
+}
+
+func checkCommand(args []string) (error, int) {
+	command := newCommand("check", "[./file1.bir ./file2.bir ...]")
+	flags := addProjectFlags(command)
+	command.Parse(args)
+
+	err, fileNames, options := loadProject(command, flags)
 	if err != nil {
-		panic(err)
+		return err, 2
 	}
 
-	text := string(data)
+	err = compiler.Check(fileNames, options)
+	if err != nil {
+		return err, 1
+	}
 
-	lexer := newLexer(text)
-	parser := newParser(lexer)
+	return nil, 0
+}
+
+func buildCommand(args []string) (error, int) {
+	command := newCommand("build", "[./file1.bir ./file2.bir ...]")
+	flags := addProjectFlags(command)
+	toolchain := addToolchainFlags(command)
+	output := command.String("o", "", "path of the produced executable or library (default \"./output.exe\", \"./liboutput.a\" or \"./liboutput.so\")")
+	library := command.String("lib", "", "build a library instead of an executable: static, shared")
+	exportC := command.Bool("export-c", false, "keep the names of exported functions and write a C header for every module declaring them")
+	command.Parse(args)
 
-	err, root := parser.Parse()
+	err, fileNames, options := loadProject(command, flags)
 	if err != nil {
-		return err, nil
+		return err, 2
 	}
 
-	fmt.Println("==============================================================================================================")
-	root.Dump(0, &[]int{}, "")
-	fmt.Println("==============================================================================================================")
+	if *library != "" {
+		options.Library = *library
+	}
+
+	if *exportC {
+		options.ExportC = true
+	}
 
-	return nil, root
+	err = toolchain.apply(options)
+	if err != nil {
+		return err, 2
+	}
+
+	if *output != "" {
+		options.ProgramName = *output
+	}
+
+	err = compiler.Build(fileNames, options)
+	if err != nil {
+		return err, 1
+	}
+
+	return nil, 0
 }
 
-func main() {
-	programName := os.Args[0]
-	args := os.Args[1:]
+func runCommand(args []string) (error, int) {
+	command := newCommand("run", "[./file1.bir ./file2.bir ...] [-- program arguments]")
+	flags := addProjectFlags(command)
+	toolchain := addToolchainFlags(command)
+	interpret := command.Bool("interp", false, "execute the sources with the interpreter instead of building them, clang is not needed")
 
-	if len(args) == 0 {
-		fmt.Printf("Usage: %s ./file1.bir [./file2.bir ...]", programName)
-		return
+	var programArguments []string
+	for index, arg := range args {
+		if arg == "--" {
+			programArguments = args[index+1:]
+			args = args[:index]
+			break
+		}
 	}
 
-	var roots []*Node
-	for _, arg := range args {
-		err, root := parseFile(arg)
+	command.Parse(args)
+
+	err, fileNames, options := loadProject(command, flags)
+	if err != nil {
+		return err, 2
+	}
+
+	if *interpret {
+		err, exitCode := compiler.Interpret(fileNames, options)
 		if err != nil {
-			panic(err)
+			return err, 1
 		}
 
-		roots = append(roots, root)
+		return nil, exitCode
 	}
 
-	checker := newChecker(roots)
+	err = toolchain.apply(options)
+	if err != nil {
+		return err, 2
+	}
 
-	err := checker.Check()
+	err, exitCode := compiler.Run(fileNames, programArguments, options)
 	if err != nil {
-		panic(err)
+		return err, 1
 	}
 
-	outputProgramName := "./output.exe"
+	return nil, exitCode
+}
 
-	linker := newLinker(roots, outputProgramName)
-	err = linker.Link()
+func emitCommand(args []string) (error, int) {
+	command := newCommand("emit", "[./file1.bir ./file2.bir ...]")
+	flags := addProjectFlags(command)
+	toolchain := addToolchainFlags(command)
+	stage := command.String("emit", "ir", "stage to stop at: "+strings.Join(compiler.EmitStages, ", "))
+	output := command.String("o", "", "output file, directory when several artifacts are produced, or - for stdout")
+	format := command.String("format", "text", "format of the tokens and ast stages: text, json")
+	command.Parse(args)
+
+	err, emitStage := compiler.ParseEmitStage(*stage)
 	if err != nil {
-		panic(err)
+		return err, 2
 	}
 
-	cmd := exec.Command(outputProgramName)
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("Invalid format: %s, expected one of: text, json", *format), 2
+	}
 
-	err = cmd.Run()
+	err, fileNames, options := loadProject(command, flags)
 	if err != nil {
-		if exitError, ok := err.(*exec.ExitError); ok {
-			fmt.Printf("Command finished with exit code: %d\n", exitError.ExitCode())
-		} else {
-			panic(err)
-		}
+		return err, 2
 	}
+
+	err = toolchain.apply(options)
+	if err != nil {
+		return err, 2
+	}
+
+	err = compiler.Emit(fileNames, emitStage, *output, *format == "json", options)
+	if err != nil {
+		return err, 1
+	}
+
+	return nil, 0
+}
+
+func replCommand(args []string) (error, int) {
+	command := newCommand("repl", "")
+	command.Parse(args)
+
+	err := compiler.RunRepl(os.Stdin, os.Stdout)
+	if err != nil {
+		return err, 1
+	}
+
+	return nil, 0
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	commandName := os.Args[1]
+	args := os.Args[2:]
+
+	var err error
+	var exitCode int
+	switch commandName {
+	case "check":
+		err, exitCode = checkCommand(args)
+	case "build":
+		err, exitCode = buildCommand(args)
+	case "run":
+		err, exitCode = runCommand(args)
+	case "emit":
+		err, exitCode = emitCommand(args)
+	case "repl":
+		err, exitCode = replCommand(args)
+	case "help", "-h", "--help":
+		usage()
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", commandName)
+		usage()
+		exitCode = 2
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, compiler.FormatError(err))
+	}
+
+	os.Exit(exitCode)
 }
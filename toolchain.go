@@ -0,0 +1,80 @@
+package main
+
+import (
+	"flag"
+
+	"bir/compiler"
+)
+
+type toolchainFlags struct {
+	compiler      *string
+	archiver      *string
+	optimization  *string
+	target        *string
+	dataLayout    *string
+	backend       *string
+	compilerFlags *stringList
+	objects       *stringList
+	libraryPaths  *stringList
+	libraries     *stringList
+	linkerFlags   *stringList
+}
+
+func addToolchainFlags(command *flag.FlagSet) *toolchainFlags {
+	flags := &toolchainFlags{
+		compiler:      command.String("cc", "", "toolchain used to assemble and link the modules (default \""+compiler.DefaultToolchain+"\")"),
+		archiver:      command.String("ar", "", "archiver used to build static libraries (default \""+compiler.DefaultArchiver+"\")"),
+		optimization:  command.String("O", "", "optimization level: 0, 1, 2, 3, s, z"),
+		target:        command.String("target", "", "target triple, the host when not given"),
+		dataLayout:    command.String("datalayout", "", "data layout of the target, asked to the toolchain when not given"),
+		backend:       command.String("backend", "", "code generated for the modules: llvm, c, wasm (default \""+compiler.BACKEND_LLVM+"\")"),
+		compilerFlags: &stringList{},
+		objects:       &stringList{},
+		libraryPaths:  &stringList{},
+		libraries:     &stringList{},
+		linkerFlags:   &stringList{},
+	}
+
+	command.Var(flags.compilerFlags, "cflag", "flag passed to the toolchain when compiling a module, can be repeated")
+	command.Var(flags.objects, "obj", "object file linked with the program, can be repeated")
+	command.Var(flags.libraryPaths, "L", "directory searched for libraries, can be repeated")
+	command.Var(flags.libraries, "l", "library linked with the program, can be repeated")
+	command.Var(flags.linkerFlags, "ldflag", "flag passed to the toolchain when linking, can be repeated")
+
+	return flags
+}
+
+// apply adds the flags to the options, they take precedence over the manifest
+func (this *toolchainFlags) apply(options *compiler.BuildOptions) error {
+	if *this.compiler != "" {
+		options.Compiler = *this.compiler
+	}
+
+	if *this.archiver != "" {
+		options.Archiver = *this.archiver
+	}
+
+	if *this.optimization != "" {
+		options.Optimization = *this.optimization
+	}
+
+	if *this.target != "" {
+		options.Target = *this.target
+	}
+
+	if *this.dataLayout != "" {
+		options.DataLayout = *this.dataLayout
+	}
+
+	if *this.backend != "" {
+		options.Backend = *this.backend
+	}
+
+	options.CompilerFlags = append(options.CompilerFlags, *this.compilerFlags...)
+	options.Objects = append(options.Objects, *this.objects...)
+	options.LibraryPaths = append(options.LibraryPaths, *this.libraryPaths...)
+	options.Libraries = append(options.Libraries, *this.libraries...)
+	options.LinkerFlags = append(options.LinkerFlags, *this.linkerFlags...)
+
+	return options.Resolve()
+}